@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	csi "github.com/awslabs/volume-modifier-for-k8s/pkg/client"
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/controller"
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/klog/v2"
+)
+
+// csiHealthChecker periodically pings the CSI driver socket in the
+// background and reports the result of the most recent probe, so the
+// healthz handler never blocks on (or is bottlenecked by) an RPC.
+type csiHealthChecker struct {
+	client  csi.Client
+	lastErr atomic.Value // error, nil-safe via the errOrNil wrapper below
+}
+
+type errOrNil struct{ err error }
+
+func newCSIHealthChecker(ctx context.Context, client csi.Client, period time.Duration) *csiHealthChecker {
+	c := &csiHealthChecker{client: client}
+	c.lastErr.Store(errOrNil{})
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			probeCtx, cancel := context.WithTimeout(ctx, period)
+			_, err := c.client.GetDriverName(probeCtx)
+			cancel()
+			c.lastErr.Store(errOrNil{err})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return c
+}
+
+func (c *csiHealthChecker) Name() string {
+	return "csi-driver"
+}
+
+func (c *csiHealthChecker) Check(_ *http.Request) error {
+	return c.lastErr.Load().(errOrNil).err
+}
+
+// reconciler is the subset of ModifyController/CRDModifyController that
+// cacheSyncChecker and workerHeartbeatChecker probe. Both reconcile paths
+// can run side by side (or not at all), so the checkers are built from
+// whichever of the two controllers are actually enabled.
+type reconciler interface {
+	CachesSynced() bool
+	LastHeartbeat() time.Time
+}
+
+// cacheSyncChecker reports healthz failures until every enabled
+// controller's informer caches have completed their initial sync.
+type cacheSyncChecker struct {
+	controllers []reconciler
+}
+
+func (c cacheSyncChecker) Name() string {
+	return "informer-cache-sync"
+}
+
+func (c cacheSyncChecker) Check(_ *http.Request) error {
+	for _, ctrl := range c.controllers {
+		if !ctrl.CachesSynced() {
+			return fmt.Errorf("informer caches not yet synced")
+		}
+	}
+	return nil
+}
+
+// workerHeartbeatChecker fails readiness if any enabled controller's
+// heartbeat hasn't been refreshed within staleAfter. The heartbeat is
+// refreshed by a ticker goroutine that Run starts once its caches sync, not
+// by the worker goroutines themselves, so a worker stuck inside a sync does
+// not make this check fail.
+type workerHeartbeatChecker struct {
+	controllers []reconciler
+	staleAfter  time.Duration
+}
+
+func (c workerHeartbeatChecker) Name() string {
+	return "worker-heartbeat"
+}
+
+func (c workerHeartbeatChecker) Check(_ *http.Request) error {
+	for _, ctrl := range c.controllers {
+		last := ctrl.LastHeartbeat()
+		if last.IsZero() {
+			return fmt.Errorf("controller has not started yet")
+		}
+		if age := time.Since(last); age > c.staleAfter {
+			return fmt.Errorf("worker heartbeat is stale (last seen %s ago)", age)
+		}
+	}
+	return nil
+}
+
+// installHealthChecks registers /healthz, /livez and /readyz on mux.
+// /livez only checks the CSI driver connection, matching kubelet's use of
+// liveness to restart a wedged process; /readyz checks the CSI driver
+// connection too, so the sidecar is unready while the CSI driver restarts,
+// and additionally requires the informer caches to be synced and each
+// controller's heartbeat to be fresh. mc and
+// crdController may each be nil if their corresponding --enable-*-api flag
+// is false, in which case only the still-enabled controller(s) are probed.
+func installHealthChecks(mux *http.ServeMux, csiClient csi.Client, mc *controller.ModifyController, crdController *controller.CRDModifyController, resyncPeriod time.Duration) {
+	var controllers []reconciler
+	if mc != nil {
+		controllers = append(controllers, mc)
+	}
+	if crdController != nil {
+		controllers = append(controllers, crdController)
+	}
+
+	csiCheck := newCSIHealthChecker(context.Background(), csiClient, 10*time.Second)
+	cacheCheck := cacheSyncChecker{controllers: controllers}
+	heartbeatCheck := workerHeartbeatChecker{controllers: controllers, staleAfter: resyncPeriod + 30*time.Second}
+
+	healthz.InstallPathHandler(mux, "/livez", csiCheck)
+	healthz.InstallPathHandler(mux, "/readyz", csiCheck, cacheCheck, heartbeatCheck)
+	healthz.InstallHandler(mux, csiCheck, cacheCheck, heartbeatCheck)
+
+	klog.V(2).Info("Installed /healthz, /livez and /readyz handlers")
+}
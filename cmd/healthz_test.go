@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCSIHealthCheckerCheck(t *testing.T) {
+	c := &csiHealthChecker{}
+
+	c.lastErr.Store(errOrNil{})
+	if err := c.Check(nil); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	probeErr := fmt.Errorf("driver socket unreachable")
+	c.lastErr.Store(errOrNil{probeErr})
+	if err := c.Check(nil); err != probeErr {
+		t.Errorf("expected %v, got %v", probeErr, err)
+	}
+}
+
+// fakeReconciler is a minimal reconciler used to drive cacheSyncChecker and
+// workerHeartbeatChecker without standing up a real controller.
+type fakeReconciler struct {
+	synced    bool
+	heartbeat time.Time
+}
+
+func (f fakeReconciler) CachesSynced() bool       { return f.synced }
+func (f fakeReconciler) LastHeartbeat() time.Time { return f.heartbeat }
+
+func TestCacheSyncCheckerCheck(t *testing.T) {
+	testCases := []struct {
+		name        string
+		controllers []reconciler
+		wantErr     bool
+	}{
+		{
+			name:        "no controllers enabled",
+			controllers: nil,
+		},
+		{
+			name:        "single controller synced",
+			controllers: []reconciler{fakeReconciler{synced: true}},
+		},
+		{
+			name:        "single controller not yet synced",
+			controllers: []reconciler{fakeReconciler{synced: false}},
+			wantErr:     true,
+		},
+		{
+			name:        "one of two controllers not yet synced",
+			controllers: []reconciler{fakeReconciler{synced: true}, fakeReconciler{synced: false}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := cacheSyncChecker{controllers: tc.controllers}
+			err := c.Check(nil)
+			if tc.wantErr && err == nil {
+				t.Errorf("expected an error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWorkerHeartbeatCheckerCheck(t *testing.T) {
+	const staleAfter = time.Minute
+
+	testCases := []struct {
+		name        string
+		controllers []reconciler
+		wantErr     bool
+	}{
+		{
+			name:        "no controllers enabled",
+			controllers: nil,
+		},
+		{
+			name:        "recent heartbeat",
+			controllers: []reconciler{fakeReconciler{heartbeat: time.Now()}},
+		},
+		{
+			name:        "heartbeat older than staleAfter",
+			controllers: []reconciler{fakeReconciler{heartbeat: time.Now().Add(-2 * staleAfter)}},
+			wantErr:     true,
+		},
+		{
+			name:        "controller never started",
+			controllers: []reconciler{fakeReconciler{}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := workerHeartbeatChecker{controllers: tc.controllers, staleAfter: staleAfter}
+			err := c.Check(nil)
+			if tc.wantErr && err == nil {
+				t.Errorf("expected an error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
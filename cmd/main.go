@@ -11,6 +11,7 @@ import (
 	"net/http/pprof"
 
 	csi "github.com/awslabs/volume-modifier-for-k8s/pkg/client"
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/client/volumemodification"
 	"github.com/awslabs/volume-modifier-for-k8s/pkg/controller"
 	"github.com/awslabs/volume-modifier-for-k8s/pkg/modifier"
 	"github.com/awslabs/volume-modifier-for-k8s/pkg/util"
@@ -48,9 +49,24 @@ var (
 	httpEndpoint = flag.String("http-endpoint", "", "The TCP network address where the HTTP server for diagnostics, including metrics and leader election health check, will listen (example: `:8080`). The default is empty string, which means the server is disabled. Only one of `--metrics-address` and `--http-endpoint` can be set.")
 	metricsPath  = flag.String("metrics-path", "/metrics", "The HTTP path where prometheus metrics will be exposed. Default is `/metrics`.")
 
+	healthProbeBindAddress = flag.String("health-probe-bind-address", "", "The TCP network address where the /healthz, /livez and /readyz endpoints will listen (example: `:8081`). Kept separate from --http-endpoint so kubelet probes don't expose pprof. The default is empty string, which means the server is disabled.")
+
 	kubeAPIQPS   = flag.Float64("kube-api-qps", 5, "QPS to use while communicating with the kubernetes apiserver. Defaults to 5.0.")
 	kubeAPIBurst = flag.Int("kube-api-burst", 10, "Burst to use while communicating with the kubernetes apiserver. Defaults to 10.")
 
+	snapshotBeforeModify = flag.Bool("snapshot-before-modify", false, "If true, take a CSI snapshot of a volume before modifying it, recording the snapshot handle as a PV annotation so the modification can be rolled back. PVCs can opt out individually via the '<driver>/disable-pre-modify-snapshot' annotation.")
+	snapshotAddress      = flag.String("snapshot-address", "", "Address of the CSI driver's snapshot socket, if served separately from --csi-address. Defaults to --csi-address.")
+	snapshotRetention    = flag.Duration("snapshot-retention", 0, "How long to keep a pre-modification snapshot around after its modification has succeeded before deleting it. Zero disables garbage collection.")
+
+	enableCRDAPI        = flag.Bool("enable-crd-api", false, "Enable the VolumeModification CRD reconcile path, an RBAC-scoped alternative to the PVC annotation API.")
+	enableAnnotationAPI = flag.Bool("enable-annotation-api", true, "Enable the '<driver>/<parameter>' PVC annotation reconcile path. Can be disabled once callers have migrated to --enable-crd-api.")
+
+	enableVolumeTypeModification = flag.Bool("enable-volume-type-modification", false, "Allow a PVC annotation change to modify '<driver>/volumeType' on its own. Disabled by default since changing a volume's type is a more disruptive operation that operators must opt into explicitly.")
+
+	modifyCooldown = flag.Duration("modify-cooldown", 6*time.Hour, "Minimum time a volume must wait between modifications, recorded as a '<driver>/last-modified-at' PV annotation. Zero disables the per-volume cooldown.")
+	modifyQPS      = flag.Float64("modify-qps", 0, "Maximum aggregate rate, in ModifyVolume calls per second, across all volumes for this driver. Zero disables the global rate limit.")
+	modifyBurst    = flag.Int("modify-burst", 1, "Maximum burst size allowed by --modify-qps.")
+
 	// Passed through ldflags.
 	version = "<unknown>"
 )
@@ -89,7 +105,11 @@ func main() {
 	informerFactory := informers.NewSharedInformerFactory(kubeClient, *resyncPeriod)
 	mux := http.NewServeMux()
 	metricsManager := metrics.NewCSIMetricsManager("" /* driverName */)
-	csiClient, err := csi.New(*csiAddress, *timeout, metricsManager)
+	snapshotAddr := *snapshotAddress
+	if snapshotAddr == "" {
+		snapshotAddr = *csiAddress
+	}
+	csiClient, err := csi.NewWithSnapshotAddress(*csiAddress, snapshotAddr, *timeout, metricsManager)
 	if err != nil {
 		klog.Fatal(err.Error())
 	}
@@ -131,19 +151,61 @@ func main() {
 	}
 
 	modifierName := csiModifier.Name()
-	mc := controller.NewModifyController(
-		modifierName,
-		csiModifier,
-		kubeClient,
-		*resyncPeriod,
-		informerFactory,
-		workqueue.NewItemExponentialFailureRateLimiter(*retryIntervalStart, *retryIntervalMax),
-		true, /* retryFailure */
-	)
+
+	// Shared between both reconcile paths, so whichever are enabled, a
+	// modification issued through one counts against the other's per-volume
+	// cooldown and global rate limit.
+	throttle := controller.NewModifyThrottle(*modifyCooldown, *modifyQPS, *modifyBurst)
+
+	var mc *controller.ModifyController
+	if *enableAnnotationAPI {
+		mc = controller.NewModifyController(
+			modifierName,
+			csiModifier,
+			kubeClient,
+			*resyncPeriod,
+			informerFactory,
+			workqueue.NewItemExponentialFailureRateLimiter(*retryIntervalStart, *retryIntervalMax),
+			true, /* retryFailure */
+			*enableVolumeTypeModification,
+			*snapshotBeforeModify,
+			*snapshotRetention,
+			throttle,
+		)
+	}
+
+	var crdController *controller.CRDModifyController
+	if *enableCRDAPI {
+		vmClient, err := volumemodification.NewForConfig(config)
+		if err != nil {
+			klog.Fatal(fmt.Errorf("build VolumeModification client failed: %v", err))
+		}
+		crdController = controller.NewCRDModifyController(modifierName, csiModifier, kubeClient, vmClient, *resyncPeriod, *snapshotBeforeModify, *snapshotRetention, throttle)
+	}
+
+	// Health probe endpoints are wired regardless of leader-election mode,
+	// and on their own bind address, so kubelet can reach them without
+	// exposing pprof and without depending on this instance holding the lease.
+	if *healthProbeBindAddress != "" {
+		healthMux := http.NewServeMux()
+		installHealthChecks(healthMux, csiClient, mc, crdController, *resyncPeriod)
+		go func() {
+			klog.Infof("Health probe server listening at %q", *healthProbeBindAddress)
+			if err := http.ListenAndServe(*healthProbeBindAddress, healthMux); err != nil {
+				klog.Fatalf("Failed to start health probe server at specified address (%q): %s", *healthProbeBindAddress, err)
+			}
+		}()
+	}
 
 	run := func(ctx context.Context) {
 		informerFactory.Start(wait.NeverStop)
-		mc.Run(*workers, ctx)
+		if mc != nil {
+			go mc.Run(*workers, ctx)
+		}
+		if crdController != nil {
+			go crdController.Run(*workers, ctx)
+		}
+		<-ctx.Done()
 	}
 
 	if !*enableLeaderElection {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	csi "github.com/awslabs/volume-modifier-for-k8s/pkg/client"
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/webhook"
+	"github.com/kubernetes-csi/csi-lib-utils/metrics"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+var (
+	clientConfigUrl = flag.String("client-config-url", "", "URL to build a client config from. Either this or kubeconfig needs to be set if the webhook is being run out of cluster.")
+	kubeConfig      = flag.String("kubeconfig", "", "Absolute path to the kubeconfig")
+
+	csiAddress = flag.String("csi-address", "/run/csi/socket", "Address of the CSI driver socket.")
+	timeout    = flag.Duration("timeout", 10*time.Second, "Timeout for waiting for CSI driver socket.")
+
+	bindAddress  = flag.String("bind-address", ":8443", "The TCP network address where the webhook HTTPS server will listen (example: `:8443`).")
+	tlsCertFile  = flag.String("tls-cert-file", "", "Path to the x509 certificate presented to the API server.")
+	tlsKeyFile   = flag.String("tls-private-key-file", "", "Path to the private key matching --tls-cert-file.")
+	validatePath = flag.String("validate-path", "/validate-pvc", "The HTTP path the ValidatingWebhookConfiguration posts AdmissionReviews to.")
+
+	enableVolumeTypeModification = flag.Bool("enable-volume-type-modification", false, "Allow a PVC annotation change to modify '<driver>/volumeType' on its own. Disabled by default since changing a volume's type is a more disruptive operation that operators must opt into explicitly.")
+	capabilityRefreshInterval    = flag.Duration("capability-refresh-interval", 5*time.Minute, "How often to re-fetch the driver's modification parameter schema.")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	var config *rest.Config
+	var err error
+	if *clientConfigUrl != "" || *kubeConfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags(*clientConfigUrl, *kubeConfig)
+	} else {
+		config, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		klog.Fatal(err.Error())
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		klog.Fatal(err.Error())
+	}
+
+	metricsManager := metrics.NewCSIMetricsManager("" /* driverName */)
+	csiClient, err := csi.New(*csiAddress, *timeout, metricsManager)
+	if err != nil {
+		klog.Fatal(err.Error())
+	}
+
+	driverName, err := csiClient.GetDriverName(context.TODO())
+	if err != nil {
+		klog.Fatal(fmt.Errorf("get driver name failed: %v", err))
+	}
+	klog.V(2).Infof("CSI driver name: %q", driverName)
+
+	h := &webhook.Handler{
+		KubeClient:                   kubeClient,
+		CSIClient:                    csiClient,
+		DriverName:                   driverName,
+		EnableVolumeTypeModification: *enableVolumeTypeModification,
+	}
+	if err := h.StartCapabilityRefresh(context.Background(), *capabilityRefreshInterval); err != nil {
+		klog.Fatal(err.Error())
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(*validatePath, h.ServeHTTP)
+
+	server := &http.Server{
+		Addr:      *bindAddress,
+		Handler:   mux,
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	klog.Infof("Webhook server listening at %q", *bindAddress)
+	klog.Fatal(server.ListenAndServeTLS(*tlsCertFile, *tlsKeyFile))
+}
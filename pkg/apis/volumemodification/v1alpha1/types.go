@@ -0,0 +1,76 @@
+// Package v1alpha1 contains the VolumeModification API, an alternative to
+// the "<driverName>/<parameter>" PVC annotation scheme that lets a
+// dedicated, RBAC-scoped resource describe a volume modification request.
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeModificationPhase is the lifecycle phase of a VolumeModification.
+type VolumeModificationPhase string
+
+const (
+	VolumeModificationPending    VolumeModificationPhase = "Pending"
+	VolumeModificationInProgress VolumeModificationPhase = "InProgress"
+	VolumeModificationSucceeded  VolumeModificationPhase = "Succeeded"
+	VolumeModificationFailed     VolumeModificationPhase = "Failed"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VolumeModification is a namespaced request to modify the volume backing a
+// PersistentVolumeClaim. It is reconciled by CRDModifyController whenever
+// --enable-crd-api is set, as an RBAC-scoped alternative to annotating the
+// PVC directly.
+type VolumeModification struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VolumeModificationSpec   `json:"spec"`
+	Status VolumeModificationStatus `json:"status,omitempty"`
+}
+
+// VolumeModificationSpec describes the desired modification.
+type VolumeModificationSpec struct {
+	// PVCRef names the PersistentVolumeClaim, in the same namespace as this
+	// VolumeModification, whose bound volume should be modified.
+	PVCRef corev1.LocalObjectReference `json:"pvcRef"`
+
+	// Parameters are passed to the CSI driver's ModifyVolumeProperties call
+	// verbatim, the same as driver-prefixed PVC annotations are today.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// DryRun resolves pvcRef to its bound PersistentVolume without calling
+	// the driver, leaving the VolumeModification in the Pending phase.
+	// Parameters are not checked.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// VolumeModificationStatus reports the outcome of reconciling the spec.
+type VolumeModificationStatus struct {
+	Phase VolumeModificationPhase `json:"phase,omitempty"`
+
+	// ObservedGeneration is the Generation most recently acted on, so
+	// clients can tell a stale status apart from one reflecting the latest
+	// spec edit.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	// SnapshotRef is the pre-modification snapshot handle, set when
+	// snapshot-before-modify applied to this modification.
+	SnapshotRef string `json:"snapshotRef,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VolumeModificationList is a list of VolumeModifications.
+type VolumeModificationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []VolumeModification `json:"items"`
+}
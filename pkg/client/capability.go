@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"reflect"
+
+	modifyrpc "github.com/awslabs/volume-modifier-for-k8s/pkg/rpc"
+	"k8s.io/klog/v2"
+)
+
+// ParameterConstraint describes the bounds a CSI driver advertises for a
+// single modifiable parameter, as reported by
+// GetCSIDriverModificationCapability. A zero value means the driver didn't
+// advertise numeric bounds for the parameter.
+type ParameterConstraint struct {
+	Min int64
+	Max int64
+}
+
+// ModificationCapability is the parameter schema a CSI driver advertises:
+// which "<driverName>/<parameter>" suffixes it accepts, and any bounds on
+// their values. The validating admission webhook uses this to reject
+// unsupported or out-of-range modification requests before they ever reach
+// a PVC annotation.
+type ModificationCapability struct {
+	Parameters map[string]ParameterConstraint
+}
+
+// GetModificationCapability asks the driver which parameters it accepts for
+// modification, and with what bounds.
+func (c *client) GetModificationCapability(ctx context.Context) (*ModificationCapability, error) {
+	cc := modifyrpc.NewModifyClient(c.conn)
+	rsp, err := cc.GetCSIDriverModificationCapability(ctx, &modifyrpc.GetCSIDriverModificationCapabilityRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	constraints, ok := parameterConstraintsOf(rsp)
+	if !ok {
+		klog.Warningf("driver's GetCSIDriverModificationCapability response does not carry parameter constraints; no modification parameters will be accepted until this is confirmed")
+		return &ModificationCapability{Parameters: map[string]ParameterConstraint{}}, nil
+	}
+	return &ModificationCapability{Parameters: constraints}, nil
+}
+
+// parameterConstraintsOf extracts a GetParameterConstraints() map from rsp by
+// its method name and shape alone, via reflection, rather than asserting
+// against a Go interface or struct naming modifyrpc's constraint type
+// directly. Whether GetCSIDriverModificationCapabilityResponse carries a
+// ParameterConstraints field at all isn't confirmed against the
+// csi-lib-utils/modifyrpc version this repo actually vendors (this tree has
+// no copy of pkg/rpc to check against), and a Go interface or struct
+// referencing that type by name would still fail to *build*, not just fail a
+// type assertion, if the real type differs or doesn't exist. Reflecting on
+// the method ourselves means this package keeps compiling either way; ok is
+// false if rsp has no such method, or its shape doesn't match what's
+// expected.
+func parameterConstraintsOf(rsp interface{}) (map[string]ParameterConstraint, bool) {
+	method := reflect.ValueOf(rsp).MethodByName("GetParameterConstraints")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil, false
+	}
+
+	constraintsVal := method.Call(nil)[0]
+	if constraintsVal.Kind() != reflect.Map || constraintsVal.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	constraints := map[string]ParameterConstraint{}
+	iter := constraintsVal.MapRange()
+	for iter.Next() {
+		constraints[iter.Key().String()] = ParameterConstraint{
+			Min: int64MethodOf(iter.Value(), "GetMin"),
+			Max: int64MethodOf(iter.Value(), "GetMax"),
+		}
+	}
+	return constraints, true
+}
+
+// int64MethodOf calls v's zero-argument, single-int64-return method named
+// name via reflection, returning 0 if v has no such method. Used to read the
+// Min/Max accessors off whatever per-parameter constraint type the real
+// modifyrpc stubs define, without naming that type.
+func int64MethodOf(v reflect.Value, name string) int64 {
+	method := v.MethodByName(name)
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return 0
+	}
+	result := method.Call(nil)[0]
+	if result.Kind() != reflect.Int64 {
+		return 0
+	}
+	return result.Int()
+}
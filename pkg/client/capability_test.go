@@ -0,0 +1,54 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fakeConstraint stands in for whatever per-parameter constraint type the
+// real modifyrpc stubs define, with pointer-receiver Get accessors, the same
+// shape protoc generates.
+type fakeConstraint struct {
+	min, max int64
+}
+
+func (c *fakeConstraint) GetMin() int64 { return c.min }
+func (c *fakeConstraint) GetMax() int64 { return c.max }
+
+// responseWithConstraints stands in for a GetCSIDriverModificationCapabilityResponse
+// whose vendored type does carry a ParameterConstraints field.
+type responseWithConstraints struct{}
+
+func (responseWithConstraints) GetParameterConstraints() map[string]*fakeConstraint {
+	return map[string]*fakeConstraint{
+		"iops":       {min: 100, max: 10000},
+		"volumeType": {},
+	}
+}
+
+// responseWithoutConstraints stands in for a GetCSIDriverModificationCapabilityResponse
+// whose vendored type doesn't carry a ParameterConstraints field at all.
+type responseWithoutConstraints struct{}
+
+func TestParameterConstraintsOf(t *testing.T) {
+	t.Run("response carries parameter constraints", func(t *testing.T) {
+		constraints, ok := parameterConstraintsOf(responseWithConstraints{})
+		if !ok {
+			t.Fatalf("expected ok=true, got false")
+		}
+		want := map[string]ParameterConstraint{
+			"iops":       {Min: 100, Max: 10000},
+			"volumeType": {},
+		}
+		if !reflect.DeepEqual(constraints, want) {
+			t.Errorf("expected %+v, got %+v", want, constraints)
+		}
+	})
+
+	t.Run("response lacks parameter constraints", func(t *testing.T) {
+		constraints, ok := parameterConstraintsOf(responseWithoutConstraints{})
+		if ok {
+			t.Fatalf("expected ok=false, got true with %+v", constraints)
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/awslabs/volume-modifier-for-k8s/pkg/csi-lib-utils/connection"
+	csispec "github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/kubernetes-csi/csi-lib-utils/metrics"
 	"github.com/kubernetes-csi/csi-lib-utils/rpc"
 	"go.opentelemetry.io/otel"
@@ -24,12 +25,38 @@ type Client interface {
 
 	SupportsVolumeModification(context.Context) error
 
+	// GetModificationCapability returns the parameter schema the driver
+	// advertises for modification, used by the validating admission
+	// webhook to reject requests the driver won't accept.
+	GetModificationCapability(context.Context) (*ModificationCapability, error)
+
 	Modify(ctx context.Context, volumeID string, params, reqContext map[string]string) error
 
+	// CreateSnapshot asks the CSI driver's snapshot socket for a snapshot of
+	// volumeID, returning the driver-assigned snapshot ID if the driver
+	// reports it ReadyToUse in its response. A snapshot that is not yet
+	// ReadyToUse is returned as an error; the call does not wait for it. It
+	// is used to take a pre-modification rollback point and is only called
+	// when snapshot-before-modify is enabled.
+	CreateSnapshot(ctx context.Context, volumeID, snapshotName string, params map[string]string) (snapshotID string, err error)
+
+	// DeleteSnapshot removes a previously created pre-modification snapshot,
+	// used for garbage collection once its retention period has elapsed.
+	DeleteSnapshot(ctx context.Context, snapshotID string) error
+
 	CloseConnection()
 }
 
 func New(addr string, timeout time.Duration, metricsmanager metrics.CSIMetricsManager) (Client, error) {
+	return NewWithSnapshotAddress(addr, addr, timeout, metricsmanager)
+}
+
+// NewWithSnapshotAddress behaves like New, but issues CreateSnapshot and
+// DeleteSnapshot calls against snapshotAddr instead of addr. Drivers that
+// serve controller and snapshot services from the same socket can pass the
+// same address for both; drivers with a companion snapshot sidecar pass its
+// socket as snapshotAddr.
+func NewWithSnapshotAddress(addr, snapshotAddr string, timeout time.Duration, metricsmanager metrics.CSIMetricsManager) (Client, error) {
 	// Create an OTLP exporter to the OpenTelemetry Collector.
 	ctx := context.Background()
 	exporter, err := otlptracegrpc.New(ctx)
@@ -55,6 +82,13 @@ func New(addr string, timeout time.Duration, metricsmanager metrics.CSIMetricsMa
 	otel.SetTracerProvider(traceProvider)
 	otel.SetTextMapPropagator(propagation.TraceContext{})
 
+	// Create an OTLP metrics pipeline alongside the trace pipeline, so the
+	// same collector configured via OTEL_EXPORTER_OTLP_* receives both.
+	inst, err := newInstruments(ctx, resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP metrics exporter: %w", err)
+	}
+
 	// Connect to the CSI driver.
 	conn, err := connection.ConnectWithOtelGrpcInterceptor(addr, metricsmanager, connection.OnConnectionLoss(connection.ExitOnConnectionLoss()))
 	if err != nil {
@@ -66,17 +100,36 @@ func New(addr string, timeout time.Duration, metricsmanager metrics.CSIMetricsMa
 		return nil, fmt.Errorf("failed probing CSI driver: %w", err)
 	}
 
+	snapshotConn := conn
+	if snapshotAddr != "" && snapshotAddr != addr {
+		snapshotConn, err = connection.ConnectWithOtelGrpcInterceptor(snapshotAddr, metricsmanager, connection.OnConnectionLoss(connection.ExitOnConnectionLoss()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to CSI snapshot socket: %w", err)
+		}
+		if err := rpc.ProbeForever(snapshotConn, timeout); err != nil {
+			return nil, fmt.Errorf("failed probing CSI snapshot socket: %w", err)
+		}
+	}
+
 	return &client{
-		conn: conn,
+		conn:         conn,
+		snapshotConn: snapshotConn,
+		instruments:  inst,
 	}, nil
 }
 
 type client struct {
-	conn *grpc.ClientConn
+	conn         *grpc.ClientConn
+	snapshotConn *grpc.ClientConn
+	instruments  *instruments
 }
 
 func (c *client) GetDriverName(ctx context.Context) (string, error) {
-	return rpc.GetDriverName(ctx, c.conn)
+	name, err := rpc.GetDriverName(ctx, c.conn)
+	if err == nil {
+		c.instruments.setDriverName(name)
+	}
+	return name, err
 }
 
 func (c *client) SupportsVolumeModification(ctx context.Context) error {
@@ -87,6 +140,9 @@ func (c *client) SupportsVolumeModification(ctx context.Context) error {
 }
 
 func (c *client) Modify(ctx context.Context, volumeID string, params, reqContext map[string]string) error {
+	c.instruments.inFlight.Add(ctx, 1)
+	start := time.Now()
+
 	cc := modifyrpc.NewModifyClient(c.conn)
 	req := &modifyrpc.ModifyVolumePropertiesRequest{
 		Name:       volumeID,
@@ -94,12 +150,43 @@ func (c *client) Modify(ctx context.Context, volumeID string, params, reqContext
 		Context:    reqContext,
 	}
 	_, err := cc.ModifyVolumeProperties(ctx, req)
+
+	c.instruments.inFlight.Add(ctx, -1)
+	c.instruments.recordModify(ctx, time.Since(start).Seconds(), err)
+
 	if err == nil {
 		klog.V(4).InfoS("Volume modification completed", "volumeID", volumeID)
 	}
 	return err
 }
 
+func (c *client) CreateSnapshot(ctx context.Context, volumeID, snapshotName string, params map[string]string) (string, error) {
+	cc := csispec.NewControllerClient(c.snapshotConn)
+	req := &csispec.CreateSnapshotRequest{
+		SourceVolumeId: volumeID,
+		Name:           snapshotName,
+		Parameters:     params,
+	}
+	rsp, err := cc.CreateSnapshot(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if !rsp.Snapshot.ReadyToUse {
+		return "", fmt.Errorf("snapshot %q of volume %q is not ready to use", snapshotName, volumeID)
+	}
+	klog.V(4).InfoS("Pre-modification snapshot created", "volumeID", volumeID, "snapshotID", rsp.Snapshot.SnapshotId)
+	return rsp.Snapshot.SnapshotId, nil
+}
+
+func (c *client) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	cc := csispec.NewControllerClient(c.snapshotConn)
+	_, err := cc.DeleteSnapshot(ctx, &csispec.DeleteSnapshotRequest{SnapshotId: snapshotID})
+	return err
+}
+
 func (c *client) CloseConnection() {
 	c.conn.Close()
+	if c.snapshotConn != c.conn {
+		c.snapshotConn.Close()
+	}
 }
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeClient is an in-memory implementation of Client for use in unit tests.
+// It never dials a real CSI socket.
+type FakeClient struct {
+	driverName           string
+	supportsModification bool
+	returnsError         bool
+	capability           *ModificationCapability
+
+	mux                     sync.Mutex
+	modifyCallCount         int
+	deleteSnapshotCallCount int
+}
+
+// NewFakeClient returns a FakeClient that reports driverName as the driver
+// name, advertises volume modification support according to
+// supportsModification, and, when returnsError is true, fails every call to
+// Modify, CreateSnapshot and DeleteSnapshot.
+func NewFakeClient(driverName string, supportsModification, returnsError bool) *FakeClient {
+	return &FakeClient{
+		driverName:           driverName,
+		supportsModification: supportsModification,
+		returnsError:         returnsError,
+	}
+}
+
+func (c *FakeClient) GetDriverName(ctx context.Context) (string, error) {
+	return c.driverName, nil
+}
+
+func (c *FakeClient) SupportsVolumeModification(ctx context.Context) error {
+	if !c.supportsModification {
+		return fmt.Errorf("driver %q does not support volume modification", c.driverName)
+	}
+	return nil
+}
+
+func (c *FakeClient) Modify(ctx context.Context, volumeID string, params, reqContext map[string]string) error {
+	c.mux.Lock()
+	c.modifyCallCount++
+	c.mux.Unlock()
+
+	if c.returnsError {
+		return fmt.Errorf("fake modify error for volume %q", volumeID)
+	}
+	return nil
+}
+
+// SetModificationCapability overrides the schema GetModificationCapability
+// returns, for tests that exercise driver-advertised parameter bounds.
+func (c *FakeClient) SetModificationCapability(capability *ModificationCapability) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.capability = capability
+}
+
+func (c *FakeClient) GetModificationCapability(ctx context.Context) (*ModificationCapability, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.capability == nil {
+		return &ModificationCapability{Parameters: map[string]ParameterConstraint{}}, nil
+	}
+	return c.capability, nil
+}
+
+func (c *FakeClient) CreateSnapshot(ctx context.Context, volumeID, snapshotName string, params map[string]string) (string, error) {
+	if c.returnsError {
+		return "", fmt.Errorf("fake snapshot error for volume %q", volumeID)
+	}
+	return "fake-snap-" + volumeID, nil
+}
+
+func (c *FakeClient) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	c.mux.Lock()
+	c.deleteSnapshotCallCount++
+	c.mux.Unlock()
+
+	if c.returnsError {
+		return fmt.Errorf("fake delete snapshot error for %q", snapshotID)
+	}
+	return nil
+}
+
+// GetDeleteSnapshotCallCount returns the number of times DeleteSnapshot has
+// been called.
+func (c *FakeClient) GetDeleteSnapshotCallCount() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.deleteSnapshotCallCount
+}
+
+func (c *FakeClient) CloseConnection() {}
+
+// GetModifyCallCount returns the number of times Modify has been called.
+func (c *FakeClient) GetModifyCallCount() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.modifyCallCount
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/grpc/status"
+)
+
+// instruments holds the OTLP metric instruments emitted around every
+// ModifyVolumeProperties call. They are created once per client and respect
+// the standard OTEL_EXPORTER_OTLP_* environment variables for endpoint and
+// header configuration, the same as the trace exporter.
+type instruments struct {
+	requests  metric.Int64Counter
+	latency   metric.Float64Histogram
+	errors    metric.Int64Counter
+	inFlight  metric.Int64UpDownCounter
+	driverVal atomic.Value // string, set once the driver name is known
+}
+
+func newInstruments(ctx context.Context, res *resource.Resource) (*instruments, error) {
+	exporter, err := otlpmetricgrpc.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(res),
+	)
+
+	// Register the meter provider as global so other packages (e.g. the
+	// reconcile loop in pkg/controller) can emit metrics through the same
+	// OTLP pipeline without needing their own exporter configuration.
+	otel.SetMeterProvider(provider)
+
+	meter := provider.Meter("github.com/awslabs/volume-modifier-for-k8s/pkg/client")
+
+	requests, err := meter.Int64Counter("modify_volume_requests_total",
+		metric.WithDescription("Number of ModifyVolumeProperties requests issued, by driver"))
+	if err != nil {
+		return nil, err
+	}
+
+	latency, err := meter.Float64Histogram("modify_volume_duration_seconds",
+		metric.WithDescription("Latency of ModifyVolumeProperties calls, by driver"))
+	if err != nil {
+		return nil, err
+	}
+
+	errs, err := meter.Int64Counter("modify_volume_errors_total",
+		metric.WithDescription("Number of ModifyVolumeProperties calls that returned an error, by driver and grpc code"))
+	if err != nil {
+		return nil, err
+	}
+
+	inFlight, err := meter.Int64UpDownCounter("modify_volume_in_flight",
+		metric.WithDescription("Number of ModifyVolumeProperties calls currently in flight, by driver"))
+	if err != nil {
+		return nil, err
+	}
+
+	i := &instruments{requests: requests, latency: latency, errors: errs, inFlight: inFlight}
+	i.driverVal.Store("")
+	return i, nil
+}
+
+func (i *instruments) setDriverName(name string) {
+	i.driverVal.Store(name)
+}
+
+func (i *instruments) driverName() string {
+	return i.driverVal.Load().(string)
+}
+
+// recordModify instruments a single completed Modify call: request count,
+// latency, and (on failure) an error count broken down by grpc code. The
+// in-flight gauge is updated by Modify itself, not here.
+func (i *instruments) recordModify(ctx context.Context, latencySeconds float64, err error) {
+	driver := attribute.String("driver", i.driverName())
+
+	i.requests.Add(ctx, 1, metric.WithAttributes(driver))
+	i.latency.Record(ctx, latencySeconds, metric.WithAttributes(driver))
+
+	if err != nil {
+		code := attribute.String("grpc_code", status.Code(err).String())
+		i.errors.Add(ctx, 1, metric.WithAttributes(driver, code))
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newTestInstruments(t *testing.T) (*instruments, *sdkmetric.ManualReader) {
+	t.Helper()
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	requests, err := meter.Int64Counter("modify_volume_requests_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	latency, err := meter.Float64Histogram("modify_volume_duration_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := meter.Int64Counter("modify_volume_errors_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inFlight, err := meter.Int64UpDownCounter("modify_volume_in_flight")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := &instruments{requests: requests, latency: latency, errors: errs, inFlight: inFlight}
+	i.driverVal.Store("")
+	return i, reader
+}
+
+func sumInt64(t *testing.T, rm metricdata.ResourceMetrics, name string) int64 {
+	t.Helper()
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("metric %q is not an int64 sum", name)
+			}
+			var total int64
+			for _, dp := range sum.DataPoints {
+				total += dp.Value
+			}
+			return total
+		}
+	}
+	return 0
+}
+
+func TestInstrumentsRecordModify(t *testing.T) {
+	i, reader := newTestInstruments(t)
+	i.setDriverName("ebs.csi.aws.com")
+
+	i.recordModify(context.Background(), 0.5, nil)
+	i.recordModify(context.Background(), 0.75, fmt.Errorf("modify failed"))
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sumInt64(t, rm, "modify_volume_requests_total"); got != 2 {
+		t.Errorf("expected 2 recorded requests, got %d", got)
+	}
+	if got := sumInt64(t, rm, "modify_volume_errors_total"); got != 1 {
+		t.Errorf("expected 1 recorded error, got %d", got)
+	}
+}
+
+func TestInstrumentsDriverName(t *testing.T) {
+	i, _ := newTestInstruments(t)
+
+	if got := i.driverName(); got != "" {
+		t.Errorf("expected empty driver name before setDriverName, got %q", got)
+	}
+
+	i.setDriverName("ebs.csi.aws.com")
+	if got := i.driverName(); got != "ebs.csi.aws.com" {
+		t.Errorf("expected driver name %q, got %q", "ebs.csi.aws.com", got)
+	}
+}
@@ -0,0 +1,95 @@
+// Package volumemodification provides a minimal typed client for the
+// VolumeModification CRD, scoped to what CRDModifyController's reconcile
+// path needs (list/watch for the informer, and status updates).
+package volumemodification
+
+import (
+	"context"
+
+	volumemodv1alpha1 "github.com/awslabs/volume-modifier-for-k8s/pkg/apis/volumemodification/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+)
+
+// Interface is the subset of a generated clientset's typed interface that
+// CRDModifyController's reconcile path needs.
+type Interface interface {
+	VolumeModifications(namespace string) VolumeModificationInterface
+}
+
+// VolumeModificationInterface mirrors the per-resource interface a
+// client-gen clientset would produce for VolumeModification.
+type VolumeModificationInterface interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*volumemodv1alpha1.VolumeModificationList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*volumemodv1alpha1.VolumeModification, error)
+	UpdateStatus(ctx context.Context, vm *volumemodv1alpha1.VolumeModification, opts metav1.UpdateOptions) (*volumemodv1alpha1.VolumeModification, error)
+}
+
+type clientset struct {
+	restClient rest.Interface
+}
+
+// NewForConfig builds a VolumeModification client from a rest.Config, the
+// same way a client-gen clientset's NewForConfig would.
+func NewForConfig(c *rest.Config) (Interface, error) {
+	config := *c
+	config.GroupVersion = &volumemodv1alpha1.SchemeGroupVersion
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = serializer.NewCodecFactory(scheme).WithoutConversion()
+
+	restClient, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &clientset{restClient: restClient}, nil
+}
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntimeMust(volumemodv1alpha1.AddToScheme(scheme))
+}
+
+func utilruntimeMust(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *clientset) VolumeModifications(namespace string) VolumeModificationInterface {
+	return &volumeModifications{restClient: c.restClient, ns: namespace}
+}
+
+type volumeModifications struct {
+	restClient rest.Interface
+	ns         string
+}
+
+const resourcePlural = "volumemodifications"
+
+func (c *volumeModifications) List(ctx context.Context, opts metav1.ListOptions) (*volumemodv1alpha1.VolumeModificationList, error) {
+	result := &volumemodv1alpha1.VolumeModificationList{}
+	err := c.restClient.Get().Namespace(c.ns).Resource(resourcePlural).VersionedParams(&opts, metav1.ParameterCodec).Do(ctx).Into(result)
+	return result, err
+}
+
+func (c *volumeModifications) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.Get().Namespace(c.ns).Resource(resourcePlural).VersionedParams(&opts, metav1.ParameterCodec).Watch(ctx)
+}
+
+func (c *volumeModifications) Get(ctx context.Context, name string, opts metav1.GetOptions) (*volumemodv1alpha1.VolumeModification, error) {
+	result := &volumemodv1alpha1.VolumeModification{}
+	err := c.restClient.Get().Namespace(c.ns).Resource(resourcePlural).Name(name).VersionedParams(&opts, metav1.ParameterCodec).Do(ctx).Into(result)
+	return result, err
+}
+
+func (c *volumeModifications) UpdateStatus(ctx context.Context, vm *volumemodv1alpha1.VolumeModification, opts metav1.UpdateOptions) (*volumemodv1alpha1.VolumeModification, error) {
+	result := &volumemodv1alpha1.VolumeModification{}
+	err := c.restClient.Put().Namespace(c.ns).Resource(resourcePlural).Name(vm.Name).SubResource("status").VersionedParams(&opts, metav1.ParameterCodec).Body(vm).Do(ctx).Into(result)
+	return result, err
+}
@@ -0,0 +1,324 @@
+// Package controller reconciles driver-prefixed modification annotations on
+// PersistentVolumeClaims against the CSI volumes backing their bound
+// PersistentVolumes.
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/modifier"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const (
+	modifyVolumeFailedReason    = "ExternalModifyVolumeFailed"
+	modifyVolumeSucceededReason = "ExternalModifyVolumeSucceeded"
+
+	volumeTypeParameterSuffix = "volumeType"
+)
+
+// ModifyController watches PersistentVolumeClaims for annotations of the
+// form "<driverName>/<parameter>" and reconciles the bound
+// PersistentVolume's underlying CSI volume to match, mirroring the applied
+// parameters back onto the PV as annotations once the modification succeeds.
+type ModifyController struct {
+	name     string
+	modifier modifier.Modifier
+	client   kubernetes.Interface
+
+	pvcLister       corelisters.PersistentVolumeClaimLister
+	pvcListerSynced cache.InformerSynced
+	pvLister        corelisters.PersistentVolumeLister
+	pvListerSynced  cache.InformerSynced
+
+	pvcQueue workqueue.RateLimitingInterface
+
+	retryFailure                 bool
+	enableVolumeTypeModification bool
+
+	snapshotBeforeModify bool
+	snapshotRetention    time.Duration
+
+	// throttle enforces the per-volume cooldown and global ModifyVolume
+	// rate limit, shared with CRDModifyController so neither reconcile path
+	// can bypass the other's limits.
+	throttle *ModifyThrottle
+
+	eventRecorder record.EventRecorder
+	metrics       *controllerMetrics
+
+	lastHeartbeat atomic.Value // time.Time, refreshed every 10s by a goroutine Run starts, not by the workers
+}
+
+// NewModifyController creates a ModifyController driven by the PVC and PV
+// informers of informerFactory. rateLimiter controls the backoff applied to
+// PVCs that fail to reconcile when retryFailure is true. throttle caps
+// ModifyVolume calls themselves (as opposed to retries of failed ones) and
+// should be the same instance passed to CRDModifyController, if enabled.
+func NewModifyController(
+	name string,
+	modifier modifier.Modifier,
+	client kubernetes.Interface,
+	resyncPeriod time.Duration,
+	informerFactory informers.SharedInformerFactory,
+	rateLimiter workqueue.RateLimiter,
+	retryFailure bool,
+	enableVolumeTypeModification bool,
+	snapshotBeforeModify bool,
+	snapshotRetention time.Duration,
+	throttle *ModifyThrottle,
+) *ModifyController {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(klog.Infof)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	eventRecorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: fmt.Sprintf("volume-modifier-%s", name)})
+
+	ctrlMetrics, err := newControllerMetrics(name)
+	if err != nil {
+		klog.Errorf("Failed to create controller metrics, reconcile counters will not be emitted: %v", err)
+	}
+
+	pvcInformer := informerFactory.Core().V1().PersistentVolumeClaims()
+	pvInformer := informerFactory.Core().V1().PersistentVolumes()
+
+	ctrl := &ModifyController{
+		name:                         name,
+		modifier:                     modifier,
+		client:                       client,
+		pvcLister:                    pvcInformer.Lister(),
+		pvcListerSynced:              pvcInformer.Informer().HasSynced,
+		pvLister:                     pvInformer.Lister(),
+		pvListerSynced:               pvInformer.Informer().HasSynced,
+		pvcQueue:                     workqueue.NewRateLimitingQueue(rateLimiter),
+		retryFailure:                 retryFailure,
+		enableVolumeTypeModification: enableVolumeTypeModification,
+		snapshotBeforeModify:         snapshotBeforeModify,
+		snapshotRetention:            snapshotRetention,
+		throttle:                     throttle,
+		eventRecorder:                eventRecorder,
+		metrics:                      ctrlMetrics,
+	}
+	ctrl.lastHeartbeat.Store(time.Time{})
+
+	pvcInformer.Informer().AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.enqueuePVC,
+		UpdateFunc: func(old, new interface{}) { ctrl.enqueuePVC(new) },
+	}, resyncPeriod)
+
+	return ctrl
+}
+
+func (ctrl *ModifyController) enqueuePVC(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Failed to get key for object: %v", err)
+		return
+	}
+	ctrl.pvcQueue.Add(key)
+	ctrl.metrics.recordEnqueued(context.Background())
+}
+
+// Run starts workers workers to process the PVC queue. It blocks until ctx
+// is cancelled.
+func (ctrl *ModifyController) Run(workers int, ctx context.Context) {
+	defer ctrl.pvcQueue.ShutDown()
+
+	klog.Infof("Starting modifier controller for driver %q", ctrl.name)
+	defer klog.Infof("Shutting down modifier controller for driver %q", ctrl.name)
+
+	if !cache.WaitForCacheSync(ctx.Done(), ctrl.pvcListerSynced, ctrl.pvListerSynced) {
+		klog.Errorf("Cannot sync caches for driver %q", ctrl.name)
+		return
+	}
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(func() { ctrl.runWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	// Refresh the heartbeat independently of how busy the workers are, so a
+	// readiness probe can tell a live-but-idle controller apart from one
+	// whose Run loop has deadlocked or exited.
+	go wait.Until(func() { ctrl.lastHeartbeat.Store(time.Now()) }, 10*time.Second, ctx.Done())
+
+	<-ctx.Done()
+}
+
+// CachesSynced reports whether the PVC and PV informer caches have
+// completed their initial sync.
+func (ctrl *ModifyController) CachesSynced() bool {
+	return ctrl.pvcListerSynced() && ctrl.pvListerSynced()
+}
+
+// LastHeartbeat returns the last time Run's worker heartbeat fired. It is
+// the zero time if Run has not been called yet.
+func (ctrl *ModifyController) LastHeartbeat() time.Time {
+	return ctrl.lastHeartbeat.Load().(time.Time)
+}
+
+func (ctrl *ModifyController) runWorker(ctx context.Context) {
+	for ctrl.processNextWorkItem(ctx) {
+	}
+}
+
+func (ctrl *ModifyController) processNextWorkItem(ctx context.Context) bool {
+	key, shutdown := ctrl.pvcQueue.Get()
+	if shutdown {
+		return false
+	}
+	defer ctrl.pvcQueue.Done(key)
+
+	if err := ctrl.syncPVC(ctx, key.(string)); err != nil {
+		if ctrl.retryFailure {
+			ctrl.pvcQueue.AddRateLimited(key)
+			ctrl.metrics.recordRetry(ctx)
+			klog.Errorf("Error syncing PVC %q, will retry: %v", key, err)
+		} else {
+			ctrl.metrics.recordTerminalFailure(ctx)
+			klog.Errorf("Error syncing PVC %q, not retrying: %v", key, err)
+		}
+		return true
+	}
+
+	ctrl.pvcQueue.Forget(key)
+	return true
+}
+
+func (ctrl *ModifyController) syncPVC(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	pvc, err := ctrl.pvcLister.PersistentVolumeClaims(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if pvc.Status.Phase != v1.ClaimBound || pvc.Spec.VolumeName == "" {
+		return nil
+	}
+
+	params := ctrl.modifiableParameters(pvc)
+	if len(params) == 0 {
+		return nil
+	}
+
+	pv, err := ctrl.pvLister.Get(pvc.Spec.VolumeName)
+	if err != nil {
+		return err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != ctrl.name {
+		return nil
+	}
+
+	if alreadyApplied(pv.Annotations, params) {
+		ctrl.maybeGCSnapshot(ctx, pv)
+		return nil
+	}
+
+	if remaining := ctrl.throttle.cooldownRemaining(pv, ctrl.name); remaining > 0 {
+		ctrl.eventRecorder.Eventf(pvc, v1.EventTypeNormal, modifyVolumeCooldownReason, "volume was modified recently, retrying in %s", remaining.Round(time.Second))
+		ctrl.pvcQueue.AddAfter(key, remaining)
+		return nil
+	}
+
+	if delay := ctrl.throttle.reserve(); delay > 0 {
+		ctrl.eventRecorder.Eventf(pvc, v1.EventTypeNormal, modifyVolumeRateLimitedReason, "global modify rate limit reached, retrying in %s", delay.Round(time.Second))
+		ctrl.pvcQueue.AddAfter(key, delay)
+		return nil
+	}
+
+	if ctrl.snapshotBeforeModify && !snapshotOptedOut(pvc, ctrl.name) {
+		pv, err = ctrl.takePreModifySnapshot(ctx, pv, pvc)
+		if err != nil {
+			ctrl.eventRecorder.Eventf(pvc, v1.EventTypeWarning, preModifySnapshotFailedReason, "error taking pre-modification snapshot: %v", err)
+			return err
+		}
+	}
+
+	if err := ctrl.modifier.Modify(ctx, pv, params); err != nil {
+		ctrl.eventRecorder.Eventf(pvc, v1.EventTypeWarning, modifyVolumeFailedReason, "error modifying volume: %v", err)
+		return err
+	}
+
+	return ctrl.recordModificationOnPV(ctx, pv, pvc, params)
+}
+
+func (ctrl *ModifyController) recordModificationOnPV(ctx context.Context, pv *v1.PersistentVolume, pvc *v1.PersistentVolumeClaim, params map[string]string) error {
+	pvClone := pv.DeepCopy()
+	if pvClone.Annotations == nil {
+		pvClone.Annotations = map[string]string{}
+	}
+	for k, v := range params {
+		pvClone.Annotations[k] = v
+	}
+	recordLastModifiedAt(pvClone, ctrl.name)
+
+	if _, err := ctrl.client.CoreV1().PersistentVolumes().Update(ctx, pvClone, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to record modification on PV %q: %w", pv.Name, err)
+	}
+
+	ctrl.eventRecorder.Eventf(pvc, v1.EventTypeNormal, modifyVolumeSucceededReason, "volume modified successfully")
+	return nil
+}
+
+// nonParameterAnnotationSuffixes are driver-prefixed PVC annotation suffixes
+// that control the modifier's own behavior rather than naming a CSI
+// ModifyVolume parameter. They must never be forwarded to the driver or
+// mirrored onto the PV.
+var nonParameterAnnotationSuffixes = map[string]bool{
+	disablePreModifySnapshotAnnotationSuffix: true,
+}
+
+// modifiableParameters returns the subset of pvc's annotations prefixed with
+// "<driverName>/" that should be applied as modification parameters.
+// Annotations in nonParameterAnnotationSuffixes are always excluded. The
+// volumeType parameter is further excluded unless enableVolumeTypeModification
+// is set, since changing a volume's type is a more disruptive operation that
+// operators must opt into explicitly.
+func (ctrl *ModifyController) modifiableParameters(pvc *v1.PersistentVolumeClaim) map[string]string {
+	prefix := ctrl.name + "/"
+	params := map[string]string{}
+	for k, v := range pvc.Annotations {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		suffix := strings.TrimPrefix(k, prefix)
+		if nonParameterAnnotationSuffixes[suffix] {
+			continue
+		}
+		if suffix == volumeTypeParameterSuffix && !ctrl.enableVolumeTypeModification {
+			continue
+		}
+		params[k] = v
+	}
+	return params
+}
+
+func alreadyApplied(pvAnnotations, params map[string]string) bool {
+	for k, v := range params {
+		if pvAnnotations[k] != v {
+			return false
+		}
+	}
+	return true
+}
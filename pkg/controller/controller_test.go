@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -39,6 +40,15 @@ func TestControllerRun(t *testing.T) {
 		expectSuccessfulModification           bool
 		pvcModification                        pvcModifier
 		enableVolumeTypeModification           bool
+		snapshotBeforeModify                   bool
+		snapshotRetention                      time.Duration
+		additionalPVAnnotations                map[string]string
+		modifyCooldown                         time.Duration
+		modifyQPS                              float64
+		modifyBurst                            int
+		skipPVAnnotationCheck                  bool
+		expectSnapshotGCed                     bool
+		expectNoPreModifySnapshotAnnotation    bool
 	}{
 		{
 			name:       "volume modification succeeds after updating annotation (even with volumeType annotation)",
@@ -119,6 +129,92 @@ func TestControllerRun(t *testing.T) {
 			expectedModifyVolumeCallCount: 1,
 			expectSuccessfulModification:  true,
 		},
+		{
+			name:       "volume modification takes a pre-modify snapshot when enabled",
+			driverName: "ebs.csi.aws.com",
+			pvc:        newFakePVC(),
+			pv:         newFakePV("testPVC", namespace, "test"),
+			additionalPVCAnnotations: map[string]string{
+				"ebs.csi.aws.com/iops": "5000",
+			},
+			expectedModifyVolumeCallCount: 1,
+			expectSuccessfulModification:  true,
+			snapshotBeforeModify:          true,
+		},
+		{
+			name:       "volume modification is blocked if the pre-modify snapshot fails",
+			driverName: "ebs.csi.aws.com",
+			pvc:        newFakePVC(),
+			pv:         newFakePV("testPVC", namespace, "test"),
+			additionalPVCAnnotations: map[string]string{
+				"ebs.csi.aws.com/iops": "5000",
+			},
+			expectedModifyVolumeCallCount: 0,
+			clientReturnsError:            true,
+			expectSuccessfulModification:  false,
+			snapshotBeforeModify:          true,
+		},
+		{
+			name:       "volume modification is blocked during its per-volume cooldown",
+			driverName: "ebs.csi.aws.com",
+			pvc:        newFakePVC(),
+			pv:         newFakePVRecentlyModified("testPVC", namespace, "test"),
+			additionalPVCAnnotations: map[string]string{
+				"ebs.csi.aws.com/iops": "5000",
+			},
+			expectedModifyVolumeCallCount: 0,
+			expectSuccessfulModification:  false,
+			modifyCooldown:                time.Hour,
+			skipPVAnnotationCheck:         true,
+		},
+		{
+			name:       "volume modification is blocked by the global rate limit",
+			driverName: "ebs.csi.aws.com",
+			pvc:        newFakePVC(),
+			pv:         newFakePV("testPVC", namespace, "test"),
+			additionalPVCAnnotations: map[string]string{
+				"ebs.csi.aws.com/iops": "5000",
+			},
+			expectedModifyVolumeCallCount: 0,
+			expectSuccessfulModification:  false,
+			modifyQPS:                     1,
+			modifyBurst:                   0,
+			skipPVAnnotationCheck:         true,
+		},
+		{
+			name:       "pre-modify snapshot is skipped when the PVC opts out",
+			driverName: "ebs.csi.aws.com",
+			pvc:        newFakePVC(),
+			pv:         newFakePV("testPVC", namespace, "test"),
+			additionalPVCAnnotations: map[string]string{
+				"ebs.csi.aws.com/iops":                        "5000",
+				"ebs.csi.aws.com/disable-pre-modify-snapshot": "true",
+			},
+			expectedModifyVolumeCallCount:       1,
+			expectSuccessfulModification:        true,
+			snapshotBeforeModify:                true,
+			expectNoPreModifySnapshotAnnotation: true,
+			// The opt-out annotation is never mirrored onto the PV, so the
+			// generic check below (which expects every PVC annotation there)
+			// is replaced by the explicit checks in the opt-out block.
+			skipPVAnnotationCheck: true,
+		},
+		{
+			name:       "pre-modify snapshot is garbage collected once snapshot-retention elapses",
+			driverName: "ebs.csi.aws.com",
+			pvc:        newFakePVC(),
+			pv:         newFakePVWithPreModifySnapshot("testPVC", namespace, "test", time.Now().Add(-2*time.Hour)),
+			additionalPVCAnnotations: map[string]string{
+				"ebs.csi.aws.com/iops": "5000",
+			},
+			additionalPVAnnotations: map[string]string{
+				"ebs.csi.aws.com/iops": "5000",
+			},
+			expectedModifyVolumeCallCount: 0,
+			snapshotRetention:             time.Hour,
+			expectSnapshotGCed:            true,
+			skipPVAnnotationCheck:         true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -141,6 +237,9 @@ func TestControllerRun(t *testing.T) {
 			}
 			if tc.pv != nil {
 				tc.pv.Spec.PersistentVolumeSource.CSI.Driver = driverName
+				for k, v := range tc.additionalPVAnnotations {
+					tc.pv.Annotations[k] = v
+				}
 				objects = append(objects, tc.pv)
 			}
 
@@ -152,6 +251,7 @@ func TestControllerRun(t *testing.T) {
 				t.Fatal(err)
 			}
 
+			throttle := NewModifyThrottle(tc.modifyCooldown, tc.modifyQPS, tc.modifyBurst)
 			controller := NewModifyController(
 				tc.driverName,
 				modifier,
@@ -161,6 +261,9 @@ func TestControllerRun(t *testing.T) {
 				workqueue.DefaultControllerRateLimiter(),
 				false,
 				tc.enableVolumeTypeModification,
+				tc.snapshotBeforeModify,
+				tc.snapshotRetention,
+				throttle,
 			)
 
 			stopCh := make(chan struct{})
@@ -190,6 +293,31 @@ func TestControllerRun(t *testing.T) {
 				t.Fatal(err)
 			}
 
+			if tc.expectSnapshotGCed {
+				if _, ok := updatedPV.Annotations["ebs.csi.aws.com/pre-modify-snapshot"]; ok {
+					t.Errorf("expected pre-modification snapshot annotation to be garbage collected, still present")
+				}
+				if client.GetDeleteSnapshotCallCount() != 1 {
+					t.Errorf("expected the pre-modification snapshot to be deleted once, got %d calls", client.GetDeleteSnapshotCallCount())
+				}
+			}
+
+			if tc.expectNoPreModifySnapshotAnnotation {
+				if _, ok := updatedPV.Annotations["ebs.csi.aws.com/pre-modify-snapshot"]; ok {
+					t.Errorf("expected no pre-modification snapshot annotation, found one")
+				}
+				if got := updatedPV.Annotations["ebs.csi.aws.com/iops"]; got != "5000" {
+					t.Errorf("expected iops annotation %q to be mirrored onto the PV, got %q", "5000", got)
+				}
+				if _, ok := updatedPV.Annotations["ebs.csi.aws.com/disable-pre-modify-snapshot"]; ok {
+					t.Errorf("expected the opt-out annotation not to be mirrored onto the PV, found it")
+				}
+			}
+
+			if tc.skipPVAnnotationCheck {
+				return
+			}
+
 			if tc.expectSuccessfulModification {
 				err = verifyAnnotationsOnPV(updatedPV.Annotations, tc.additionalPVCAnnotations, tc.enableVolumeTypeModification)
 			} else {
@@ -297,3 +425,21 @@ func newFakePV(pvcName, pvcNamespace string, pvcUID types.UID) *v1.PersistentVol
 	}
 	return pv
 }
+
+// newFakePVRecentlyModified is identical to newFakePV, but annotated as
+// having just been modified, to exercise the per-volume cooldown.
+func newFakePVRecentlyModified(pvcName, pvcNamespace string, pvcUID types.UID) *v1.PersistentVolume {
+	pv := newFakePV(pvcName, pvcNamespace, pvcUID)
+	pv.Annotations["ebs.csi.aws.com/last-modified-at"] = strconv.FormatInt(time.Now().Unix(), 10)
+	return pv
+}
+
+// newFakePVWithPreModifySnapshot is identical to newFakePV, but annotated
+// with a pre-modification snapshot taken at takenAt, to exercise
+// maybeGCSnapshot's retention check.
+func newFakePVWithPreModifySnapshot(pvcName, pvcNamespace string, pvcUID types.UID, takenAt time.Time) *v1.PersistentVolume {
+	pv := newFakePV(pvcName, pvcNamespace, pvcUID)
+	pv.Annotations["ebs.csi.aws.com/pre-modify-snapshot"] = "fake-snap-vol-123243434"
+	pv.Annotations["ebs.csi.aws.com/pre-modify-snapshot-time"] = strconv.FormatInt(takenAt.Unix(), 10)
+	return pv
+}
@@ -0,0 +1,308 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	volumemodv1alpha1 "github.com/awslabs/volume-modifier-for-k8s/pkg/apis/volumemodification/v1alpha1"
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/client/volumemodification"
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/modifier"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// CRDModifyController reconciles VolumeModification objects, an RBAC-scoped
+// alternative to the "<driverName>/<parameter>" PVC annotation path
+// implemented by ModifyController. The two reconcile paths run side by
+// side, gated independently by --enable-crd-api and --enable-annotation-api.
+type CRDModifyController struct {
+	name       string
+	modifier   modifier.Modifier
+	kubeClient kubernetes.Interface
+	vmClient   volumemodification.Interface
+
+	snapshotBeforeModify bool
+	snapshotRetention    time.Duration
+
+	// throttle enforces the per-volume cooldown and global ModifyVolume
+	// rate limit, shared with ModifyController so neither reconcile path
+	// can bypass the other's limits.
+	throttle *ModifyThrottle
+
+	informer cache.SharedIndexInformer
+	queue    workqueue.RateLimitingInterface
+
+	lastHeartbeat atomic.Value // time.Time, refreshed every 10s by a goroutine Run starts, not by the workers
+}
+
+// NewCRDModifyController creates a CRDModifyController for driver name,
+// watching VolumeModification objects across all namespaces via vmClient.
+// snapshotBeforeModify mirrors ModifyController's flag of the same name: a
+// rollback snapshot is taken (unless the PVC opts out) before each
+// modification, and its handle is recorded on the VolumeModification status;
+// snapshotRetention controls how long that snapshot is kept once the
+// modification succeeds before maybeGCSnapshot deletes it. throttle should
+// be the same instance passed to ModifyController, so the per-volume
+// cooldown and global rate limit apply regardless of which reconcile path a
+// given modification came through.
+func NewCRDModifyController(
+	name string,
+	modifier modifier.Modifier,
+	kubeClient kubernetes.Interface,
+	vmClient volumemodification.Interface,
+	resyncPeriod time.Duration,
+	snapshotBeforeModify bool,
+	snapshotRetention time.Duration,
+	throttle *ModifyThrottle,
+) *CRDModifyController {
+	ctrl := &CRDModifyController{
+		name:                 name,
+		modifier:             modifier,
+		kubeClient:           kubeClient,
+		vmClient:             vmClient,
+		snapshotBeforeModify: snapshotBeforeModify,
+		snapshotRetention:    snapshotRetention,
+		throttle:             throttle,
+		queue:                workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+	ctrl.lastHeartbeat.Store(time.Time{})
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return vmClient.VolumeModifications(metav1.NamespaceAll).List(context.Background(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return vmClient.VolumeModifications(metav1.NamespaceAll).Watch(context.Background(), options)
+		},
+	}
+
+	ctrl.informer = cache.NewSharedIndexInformer(lw, &volumemodv1alpha1.VolumeModification{}, resyncPeriod, cache.Indexers{})
+	ctrl.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.enqueue,
+		UpdateFunc: func(old, new interface{}) { ctrl.enqueue(new) },
+	})
+
+	return ctrl
+}
+
+func (ctrl *CRDModifyController) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Failed to get key for VolumeModification: %v", err)
+		return
+	}
+	ctrl.queue.Add(key)
+}
+
+// Run starts the VolumeModification informer and workers workers to drain
+// its queue. It blocks until ctx is cancelled.
+func (ctrl *CRDModifyController) Run(workers int, ctx context.Context) {
+	defer ctrl.queue.ShutDown()
+
+	go ctrl.informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), ctrl.informer.HasSynced) {
+		klog.Errorf("Cannot sync VolumeModification informer cache for driver %q", ctrl.name)
+		return
+	}
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(func() { ctrl.runWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	// Refresh the heartbeat independently of how busy the workers are, so a
+	// readiness probe can tell a live-but-idle controller apart from one
+	// whose Run loop has deadlocked or exited.
+	go wait.Until(func() { ctrl.lastHeartbeat.Store(time.Now()) }, 10*time.Second, ctx.Done())
+
+	<-ctx.Done()
+}
+
+// CachesSynced reports whether the VolumeModification informer cache has
+// completed its initial sync.
+func (ctrl *CRDModifyController) CachesSynced() bool {
+	return ctrl.informer.HasSynced()
+}
+
+// LastHeartbeat returns the last time Run's worker heartbeat fired. It is
+// the zero time if Run has not been called yet.
+func (ctrl *CRDModifyController) LastHeartbeat() time.Time {
+	return ctrl.lastHeartbeat.Load().(time.Time)
+}
+
+func (ctrl *CRDModifyController) runWorker(ctx context.Context) {
+	for ctrl.processNextWorkItem(ctx) {
+	}
+}
+
+func (ctrl *CRDModifyController) processNextWorkItem(ctx context.Context) bool {
+	key, shutdown := ctrl.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	if err := ctrl.sync(ctx, key.(string)); err != nil {
+		ctrl.queue.AddRateLimited(key)
+		klog.Errorf("Error syncing VolumeModification %q, will retry: %v", key, err)
+		return true
+	}
+
+	ctrl.queue.Forget(key)
+	return true
+}
+
+func (ctrl *CRDModifyController) sync(ctx context.Context, key string) error {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	obj, exists, err := ctrl.informer.GetStore().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	vm := obj.(*volumemodv1alpha1.VolumeModification).DeepCopy()
+
+	// Once Succeeded, vm itself never needs reconciling again, but the PV it
+	// modified may still be holding a pre-modification snapshot that has to
+	// be garbage collected once snapshotRetention elapses. Rather than
+	// short-circuiting here, fall through to the same PVC/PV lookup the
+	// unfinished path uses, and let the no-op branches below run GC instead
+	// of touching the VolumeModification's status.
+	alreadySucceeded := vm.Status.ObservedGeneration == vm.Generation && vm.Status.Phase == volumemodv1alpha1.VolumeModificationSucceeded
+
+	pvc, err := ctrl.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, vm.Spec.PVCRef.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if alreadySucceeded {
+			return nil
+		}
+		_, err := ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationFailed, fmt.Sprintf("PVC %q not found", vm.Spec.PVCRef.Name))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if pvc.Status.Phase != v1.ClaimBound || pvc.Spec.VolumeName == "" {
+		if alreadySucceeded {
+			return nil
+		}
+		_, err := ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationPending, "PVC is not yet bound")
+		return err
+	}
+
+	pv, err := ctrl.kubeClient.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != ctrl.name {
+		return nil
+	}
+
+	if alreadySucceeded {
+		ctrl.maybeGCSnapshot(ctx, pv)
+		return nil
+	}
+
+	if vm.Spec.DryRun {
+		_, err := ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationPending, "dry-run: PVC and PV resolved, no modification performed")
+		return err
+	}
+
+	if remaining := ctrl.throttle.cooldownRemaining(pv, ctrl.name); remaining > 0 {
+		_, err := ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationPending, fmt.Sprintf("volume was modified recently, retrying in %s", remaining.Round(time.Second)))
+		if err != nil {
+			return err
+		}
+		ctrl.queue.AddAfter(key, remaining)
+		return nil
+	}
+
+	if delay := ctrl.throttle.reserve(); delay > 0 {
+		_, err := ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationPending, fmt.Sprintf("global modify rate limit reached, retrying in %s", delay.Round(time.Second)))
+		if err != nil {
+			return err
+		}
+		ctrl.queue.AddAfter(key, delay)
+		return nil
+	}
+
+	vm, err = ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationInProgress, "modifying volume")
+	if err != nil {
+		return err
+	}
+
+	if ctrl.snapshotBeforeModify && !snapshotOptedOut(pvc, ctrl.name) {
+		snapshottedPV, err := ctrl.takePreModifySnapshot(ctx, pv, pvc)
+		if err != nil {
+			_, statusErr := ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationFailed, err.Error())
+			if statusErr != nil {
+				return statusErr
+			}
+			return err
+		}
+		pv = snapshottedPV
+		vm.Status.SnapshotRef = pv.Annotations[snapshotAnnotationKey(ctrl.name)]
+	}
+
+	if err := ctrl.modifier.Modify(ctx, pv, vm.Spec.Parameters); err != nil {
+		_, statusErr := ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationFailed, err.Error())
+		if statusErr != nil {
+			return statusErr
+		}
+		return err
+	}
+
+	if err := ctrl.mirrorParametersOntoPV(ctx, pv, vm.Spec.Parameters); err != nil {
+		return err
+	}
+
+	_, err = ctrl.updateStatus(ctx, vm, volumemodv1alpha1.VolumeModificationSucceeded, "volume modified successfully")
+	return err
+}
+
+// mirrorParametersOntoPV records vm.Spec.Parameters on the PV as annotations
+// under the same keys, just as ModifyController's annotation path records
+// its "<driverName>/<parameter>" keys, so both APIs leave a consistent audit trail on
+// the PV regardless of which one was used to request the modification. It
+// also stamps the last-modified-at annotation ctrl.throttle reads back, so
+// the per-volume cooldown applies to the next modification regardless of
+// which reconcile path performs it.
+func (ctrl *CRDModifyController) mirrorParametersOntoPV(ctx context.Context, pv *v1.PersistentVolume, params map[string]string) error {
+	pvClone := pv.DeepCopy()
+	if pvClone.Annotations == nil {
+		pvClone.Annotations = map[string]string{}
+	}
+	for k, v := range params {
+		pvClone.Annotations[k] = v
+	}
+	recordLastModifiedAt(pvClone, ctrl.name)
+	if _, err := ctrl.kubeClient.CoreV1().PersistentVolumes().Update(ctx, pvClone, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to mirror parameters onto PV %q: %w", pv.Name, err)
+	}
+	return nil
+}
+
+// updateStatus writes the given phase/message onto vm and returns the
+// object UpdateStatus responds with, whose ResourceVersion must be used
+// for any subsequent status write in the same sync so the optimistic
+// concurrency check doesn't reject it as stale.
+func (ctrl *CRDModifyController) updateStatus(ctx context.Context, vm *volumemodv1alpha1.VolumeModification, phase volumemodv1alpha1.VolumeModificationPhase, message string) (*volumemodv1alpha1.VolumeModification, error) {
+	vm.Status.Phase = phase
+	vm.Status.Message = message
+	vm.Status.ObservedGeneration = vm.Generation
+	return ctrl.vmClient.VolumeModifications(vm.Namespace).UpdateStatus(ctx, vm, metav1.UpdateOptions{})
+}
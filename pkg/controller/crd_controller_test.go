@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	volumemodv1alpha1 "github.com/awslabs/volume-modifier-for-k8s/pkg/apis/volumemodification/v1alpha1"
+	csi "github.com/awslabs/volume-modifier-for-k8s/pkg/client"
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/client/volumemodification"
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/modifier"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCRDControllerSync(t *testing.T) {
+	testCases := []struct {
+		name                          string
+		clientReturnsError            bool
+		dryRun                        bool
+		pvcBound                      bool
+		pvcMissing                    bool
+		snapshotBeforeModify          bool
+		recentlyModified              bool
+		modifyCooldown                time.Duration
+		modifyQPS                     float64
+		modifyBurst                   int
+		expectedModifyVolumeCallCount int
+		expectedPhase                 volumemodv1alpha1.VolumeModificationPhase
+		expectSnapshotRef             bool
+		expectSyncErr                 bool
+	}{
+		{
+			name:                          "modification succeeds and status reaches Succeeded",
+			pvcBound:                      true,
+			expectedModifyVolumeCallCount: 1,
+			expectedPhase:                 volumemodv1alpha1.VolumeModificationSucceeded,
+		},
+		{
+			name:          "PVC not found marks the VolumeModification Failed",
+			pvcMissing:    true,
+			expectedPhase: volumemodv1alpha1.VolumeModificationFailed,
+		},
+		{
+			name:          "unbound PVC marks the VolumeModification Pending",
+			pvcBound:      false,
+			expectedPhase: volumemodv1alpha1.VolumeModificationPending,
+		},
+		{
+			name:          "dry run validates without calling Modify",
+			pvcBound:      true,
+			dryRun:        true,
+			expectedPhase: volumemodv1alpha1.VolumeModificationPending,
+		},
+		{
+			name:                          "modification failure marks the VolumeModification Failed and still returns an error",
+			pvcBound:                      true,
+			clientReturnsError:            true,
+			expectedModifyVolumeCallCount: 1,
+			expectedPhase:                 volumemodv1alpha1.VolumeModificationFailed,
+			expectSyncErr:                 true,
+		},
+		{
+			name:                          "pre-modify snapshot is recorded on the status when enabled",
+			pvcBound:                      true,
+			snapshotBeforeModify:          true,
+			expectedModifyVolumeCallCount: 1,
+			expectedPhase:                 volumemodv1alpha1.VolumeModificationSucceeded,
+			expectSnapshotRef:             true,
+		},
+		{
+			name:                 "modification is blocked if the pre-modify snapshot fails",
+			pvcBound:             true,
+			snapshotBeforeModify: true,
+			clientReturnsError:   true,
+			expectedPhase:        volumemodv1alpha1.VolumeModificationFailed,
+			expectSyncErr:        true,
+		},
+		{
+			name:                          "modification is blocked during its per-volume cooldown",
+			pvcBound:                      true,
+			recentlyModified:              true,
+			modifyCooldown:                time.Hour,
+			expectedModifyVolumeCallCount: 0,
+			expectedPhase:                 volumemodv1alpha1.VolumeModificationPending,
+		},
+		{
+			name:                          "modification is blocked by the global rate limit",
+			pvcBound:                      true,
+			modifyQPS:                     1,
+			modifyBurst:                   0,
+			expectedModifyVolumeCallCount: 0,
+			expectedPhase:                 volumemodv1alpha1.VolumeModificationPending,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			const driverName = "ebs.csi.aws.com"
+
+			pv := newFakePV("testPVC", namespace, "test")
+			if tc.recentlyModified {
+				pv = newFakePVRecentlyModified("testPVC", namespace, "test")
+			}
+			pv.Spec.PersistentVolumeSource.CSI.Driver = driverName
+
+			var objects []runtime.Object
+			objects = append(objects, pv)
+			if !tc.pvcMissing {
+				pvc := newFakePVC()
+				if !tc.pvcBound {
+					pvc.Status.Phase = v1.ClaimPending
+					pvc.Spec.VolumeName = ""
+				}
+				objects = append(objects, pvc)
+			}
+
+			k8sClient := fake.NewSimpleClientset(objects...)
+
+			csiClient := csi.NewFakeClient(driverName, true, tc.clientReturnsError)
+			mod, err := modifier.NewFromClient(driverName, csiClient, k8sClient, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			vm := &volumemodv1alpha1.VolumeModification{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "testVM",
+					Namespace:       namespace,
+					Generation:      1,
+					ResourceVersion: "1",
+				},
+				Spec: volumemodv1alpha1.VolumeModificationSpec{
+					PVCRef:     v1.LocalObjectReference{Name: "testPVC"},
+					Parameters: map[string]string{driverName + "/iops": "5000"},
+					DryRun:     tc.dryRun,
+				},
+			}
+
+			vmClient := newFakeVMClient(vm)
+			ctrl := NewCRDModifyController(driverName, mod, k8sClient, vmClient, 0, tc.snapshotBeforeModify, 0, NewModifyThrottle(tc.modifyCooldown, tc.modifyQPS, tc.modifyBurst))
+			if err := ctrl.informer.GetStore().Add(vm); err != nil {
+				t.Fatal(err)
+			}
+
+			// The failure scenarios above must still return an error from
+			// sync, so the workqueue retries with backoff instead of
+			// Forgetting the key and waiting for the next full resync.
+			err = ctrl.sync(context.Background(), namespace+"/"+vm.Name)
+			if tc.expectSyncErr && err == nil {
+				t.Errorf("expected sync to return an error, got nil")
+			}
+			if !tc.expectSyncErr && err != nil {
+				t.Errorf("expected sync to return no error, got %v", err)
+			}
+
+			if csiClient.GetModifyCallCount() != tc.expectedModifyVolumeCallCount {
+				t.Errorf("unexpected modify volume call count: expected %d, got %d", tc.expectedModifyVolumeCallCount, csiClient.GetModifyCallCount())
+			}
+
+			updated := vmClient.get(namespace, vm.Name)
+			if updated.Status.Phase != tc.expectedPhase {
+				t.Errorf("unexpected phase: expected %q, got %q (message: %q)", tc.expectedPhase, updated.Status.Phase, updated.Status.Message)
+			}
+
+			if tc.expectSnapshotRef && updated.Status.SnapshotRef == "" {
+				t.Errorf("expected a SnapshotRef to be recorded, got none")
+			}
+			if !tc.expectSnapshotRef && updated.Status.SnapshotRef != "" {
+				t.Errorf("expected no SnapshotRef, got %q", updated.Status.SnapshotRef)
+			}
+		})
+	}
+}
+
+// fakeVMClient is an in-memory volumemodification.Interface that enforces
+// ResourceVersion checks on UpdateStatus, the same as a real API server's
+// optimistic concurrency control, so a regression that reuses a stale
+// ResourceVersion across the multiple status writes in one sync fails the
+// test with a conflict instead of silently succeeding.
+type fakeVMClient struct {
+	mu      sync.Mutex
+	objects map[string]*volumemodv1alpha1.VolumeModification
+	nextRV  int
+}
+
+func newFakeVMClient(objs ...*volumemodv1alpha1.VolumeModification) *fakeVMClient {
+	c := &fakeVMClient{objects: map[string]*volumemodv1alpha1.VolumeModification{}, nextRV: 2}
+	for _, obj := range objs {
+		c.objects[obj.Namespace+"/"+obj.Name] = obj.DeepCopy()
+	}
+	return c
+}
+
+func (c *fakeVMClient) get(namespace, name string) *volumemodv1alpha1.VolumeModification {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.objects[namespace+"/"+name].DeepCopy()
+}
+
+func (c *fakeVMClient) VolumeModifications(namespace string) volumemodification.VolumeModificationInterface {
+	return &fakeVolumeModifications{client: c, namespace: namespace}
+}
+
+type fakeVolumeModifications struct {
+	client    *fakeVMClient
+	namespace string
+}
+
+func (f *fakeVolumeModifications) List(ctx context.Context, opts metav1.ListOptions) (*volumemodv1alpha1.VolumeModificationList, error) {
+	return &volumemodv1alpha1.VolumeModificationList{}, nil
+}
+
+func (f *fakeVolumeModifications) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return watch.NewFake(), nil
+}
+
+func (f *fakeVolumeModifications) Get(ctx context.Context, name string, opts metav1.GetOptions) (*volumemodv1alpha1.VolumeModification, error) {
+	f.client.mu.Lock()
+	defer f.client.mu.Unlock()
+	vm, ok := f.client.objects[f.namespace+"/"+name]
+	if !ok {
+		return nil, apierrors.NewNotFound(volumemodv1alpha1.Resource("volumemodifications"), name)
+	}
+	return vm.DeepCopy(), nil
+}
+
+func (f *fakeVolumeModifications) UpdateStatus(ctx context.Context, vm *volumemodv1alpha1.VolumeModification, opts metav1.UpdateOptions) (*volumemodv1alpha1.VolumeModification, error) {
+	f.client.mu.Lock()
+	defer f.client.mu.Unlock()
+
+	key := f.namespace + "/" + vm.Name
+	existing, ok := f.client.objects[key]
+	if !ok {
+		return nil, apierrors.NewNotFound(volumemodv1alpha1.Resource("volumemodifications"), vm.Name)
+	}
+	if vm.ResourceVersion != existing.ResourceVersion {
+		return nil, apierrors.NewConflict(volumemodv1alpha1.Resource("volumemodifications"), vm.Name, fmt.Errorf("stale ResourceVersion %q, current is %q", vm.ResourceVersion, existing.ResourceVersion))
+	}
+
+	stored := vm.DeepCopy()
+	stored.ResourceVersion = fmt.Sprintf("%d", f.client.nextRV)
+	f.client.nextRV++
+	f.client.objects[key] = stored
+
+	return stored.DeepCopy(), nil
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// controllerMetrics counts reconcile-loop events through whatever meter
+// provider pkg/client registered globally (an OTLP exporter configured via
+// OTEL_EXPORTER_OTLP_*, or a no-op provider if the client hasn't set one up).
+type controllerMetrics struct {
+	driver           attribute.KeyValue
+	enqueued         metric.Int64Counter
+	retries          metric.Int64Counter
+	terminalFailures metric.Int64Counter
+}
+
+func newControllerMetrics(driverName string) (*controllerMetrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/awslabs/volume-modifier-for-k8s/pkg/controller")
+
+	enqueued, err := meter.Int64Counter("modify_volume_reconciles_enqueued_total",
+		metric.WithDescription("Number of PVCs enqueued for volume modification reconciliation"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create enqueued counter: %w", err)
+	}
+
+	retries, err := meter.Int64Counter("modify_volume_reconciles_retried_total",
+		metric.WithDescription("Number of PVC reconciles requeued after a failed modification"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retries counter: %w", err)
+	}
+
+	terminalFailures, err := meter.Int64Counter("modify_volume_reconciles_failed_total",
+		metric.WithDescription("Number of PVC reconciles that failed without being retried"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create terminal failures counter: %w", err)
+	}
+
+	return &controllerMetrics{
+		driver:           attribute.String("driver", driverName),
+		enqueued:         enqueued,
+		retries:          retries,
+		terminalFailures: terminalFailures,
+	}, nil
+}
+
+func (m *controllerMetrics) recordEnqueued(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.enqueued.Add(ctx, 1, metric.WithAttributes(m.driver))
+}
+
+func (m *controllerMetrics) recordRetry(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.retries.Add(ctx, 1, metric.WithAttributes(m.driver))
+}
+
+func (m *controllerMetrics) recordTerminalFailure(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.terminalFailures.Add(ctx, 1, metric.WithAttributes(m.driver))
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// modifyVolumeCooldownReason and modifyVolumeRateLimitedReason describe a
+	// PVC event recorded when a modification is deferred, as opposed to
+	// failed outright: the controller will retry once the cooldown or global
+	// rate limit allows it, without engaging the queue's exponential backoff.
+	modifyVolumeCooldownReason    = "ModifyVolumeCooldown"
+	modifyVolumeRateLimitedReason = "ModifyVolumeRateLimited"
+
+	// lastModifiedAtAnnotationSuffix records the Unix time of the most
+	// recent successful modification, so repeated edits to a PVC's
+	// annotations within the cooldown don't re-trigger the CSI call.
+	lastModifiedAtAnnotationSuffix = "last-modified-at"
+)
+
+func lastModifiedAtAnnotationKey(driverName string) string {
+	return driverName + "/" + lastModifiedAtAnnotationSuffix
+}
+
+// ModifyThrottle bundles the per-volume cooldown and global token-bucket
+// rate limit applied before a ModifyVolume call. A single ModifyThrottle is
+// shared between ModifyController and CRDModifyController so that whichever
+// reconcile path(s) are enabled, neither one can bypass the other's limits.
+// Either check is disabled independently: a zero cooldown or non-positive
+// modifyQPS turns off its half of the throttle.
+type ModifyThrottle struct {
+	cooldown time.Duration
+	limiter  *rate.Limiter
+}
+
+// NewModifyThrottle builds a ModifyThrottle from the same flags controller
+// construction already accepts. modifyQPS <= 0 disables the global limiter.
+func NewModifyThrottle(cooldown time.Duration, modifyQPS float64, modifyBurst int) *ModifyThrottle {
+	var limiter *rate.Limiter
+	if modifyQPS > 0 {
+		limiter = rate.NewLimiter(rate.Limit(modifyQPS), modifyBurst)
+	}
+	return &ModifyThrottle{cooldown: cooldown, limiter: limiter}
+}
+
+// cooldownRemaining returns how long is left before pv's per-volume
+// modification cooldown expires, driverName identifying which PV annotation
+// to read.
+func (t *ModifyThrottle) cooldownRemaining(pv *v1.PersistentVolume, driverName string) time.Duration {
+	if t.cooldown <= 0 {
+		return 0
+	}
+
+	lastRaw := pv.Annotations[lastModifiedAtAnnotationKey(driverName)]
+	if lastRaw == "" {
+		return 0
+	}
+
+	lastUnix, err := strconv.ParseInt(lastRaw, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	remaining := t.cooldown - time.Since(time.Unix(lastUnix, 0))
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// reserve checks out one token from the global limiter, returning the delay
+// the caller must wait before proceeding (zero if the limiter is disabled or
+// a token was available immediately). A non-zero delay does not consume the
+// token, since the caller is expected to defer and retry later rather than
+// block on it.
+func (t *ModifyThrottle) reserve() time.Duration {
+	if t.limiter == nil {
+		return 0
+	}
+
+	reservation := t.limiter.ReserveN(time.Now(), 1)
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return delay
+	}
+	return 0
+}
+
+// recordLastModifiedAt stamps pvClone with the current time, so a later
+// reconcile of the same volume can enforce the per-volume cooldown.
+func recordLastModifiedAt(pvClone *v1.PersistentVolume, driverName string) {
+	if pvClone.Annotations == nil {
+		pvClone.Annotations = map[string]string{}
+	}
+	pvClone.Annotations[lastModifiedAtAnnotationKey(driverName)] = strconv.FormatInt(time.Now().Unix(), 10)
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/awslabs/volume-modifier-for-k8s/pkg/modifier"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	preModifySnapshotFailedReason = "PreModifySnapshotFailed"
+
+	// preModifySnapshotAnnotationSuffix is appended to the driver name to
+	// form the PV annotation recording the rollback snapshot handle, e.g.
+	// "ebs.csi.aws.com/pre-modify-snapshot".
+	preModifySnapshotAnnotationSuffix = "pre-modify-snapshot"
+
+	// preModifySnapshotTimeAnnotationSuffix records when that snapshot was
+	// taken, so maybeGCSnapshot can tell when snapshotRetention has elapsed.
+	preModifySnapshotTimeAnnotationSuffix = "pre-modify-snapshot-time"
+
+	// disablePreModifySnapshotAnnotationSuffix lets a PVC opt out of an
+	// otherwise cluster-wide --snapshot-before-modify policy.
+	disablePreModifySnapshotAnnotationSuffix = "disable-pre-modify-snapshot"
+)
+
+func snapshotAnnotationKey(driverName string) string {
+	return driverName + "/" + preModifySnapshotAnnotationSuffix
+}
+
+func snapshotTimeAnnotationKey(driverName string) string {
+	return driverName + "/" + preModifySnapshotTimeAnnotationSuffix
+}
+
+func snapshotOptedOut(pvc *v1.PersistentVolumeClaim, driverName string) bool {
+	return pvc.Annotations[driverName+"/"+disablePreModifySnapshotAnnotationSuffix] == "true"
+}
+
+// snapshotDeps bundles the fields ModifyController and CRDModifyController
+// both carry that the pre-modify snapshot helpers below need, so that logic
+// is written once and shared by both reconcile paths instead of duplicated
+// per controller.
+type snapshotDeps struct {
+	name              string
+	modifier          modifier.Modifier
+	kubeClient        kubernetes.Interface
+	snapshotRetention time.Duration
+}
+
+func (ctrl *ModifyController) snapshotDeps() snapshotDeps {
+	return snapshotDeps{name: ctrl.name, modifier: ctrl.modifier, kubeClient: ctrl.client, snapshotRetention: ctrl.snapshotRetention}
+}
+
+func (ctrl *CRDModifyController) snapshotDeps() snapshotDeps {
+	return snapshotDeps{name: ctrl.name, modifier: ctrl.modifier, kubeClient: ctrl.kubeClient, snapshotRetention: ctrl.snapshotRetention}
+}
+
+// takePreModifySnapshot creates a rollback snapshot for pv's volume and
+// records its handle (and creation time, for retention bookkeeping) as PV
+// annotations before returning the updated PV. Modify is never called unless
+// this succeeds, so a snapshot RPC failure blocks the modification entirely.
+func (ctrl *ModifyController) takePreModifySnapshot(ctx context.Context, pv *v1.PersistentVolume, pvc *v1.PersistentVolumeClaim) (*v1.PersistentVolume, error) {
+	return takePreModifySnapshot(ctx, ctrl.snapshotDeps(), pv, pvc)
+}
+
+// takePreModifySnapshot behaves the same as ModifyController's method of the
+// same name, against the CRD reconcile path's own kubeClient.
+func (ctrl *CRDModifyController) takePreModifySnapshot(ctx context.Context, pv *v1.PersistentVolume, pvc *v1.PersistentVolumeClaim) (*v1.PersistentVolume, error) {
+	return takePreModifySnapshot(ctx, ctrl.snapshotDeps(), pv, pvc)
+}
+
+func takePreModifySnapshot(ctx context.Context, deps snapshotDeps, pv *v1.PersistentVolume, pvc *v1.PersistentVolumeClaim) (*v1.PersistentVolume, error) {
+	snapshotName := fmt.Sprintf("pre-modify-%s-%s", pv.Name, pvc.ResourceVersion)
+	snapshotID, err := deps.modifier.Snapshot(ctx, pv, snapshotName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pre-modification snapshot: %w", err)
+	}
+
+	pvClone := pv.DeepCopy()
+	if pvClone.Annotations == nil {
+		pvClone.Annotations = map[string]string{}
+	}
+	pvClone.Annotations[snapshotAnnotationKey(deps.name)] = snapshotID
+	pvClone.Annotations[snapshotTimeAnnotationKey(deps.name)] = strconv.FormatInt(metav1.Now().Unix(), 10)
+
+	updated, err := deps.kubeClient.CoreV1().PersistentVolumes().Update(ctx, pvClone, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record pre-modification snapshot %q on PV %q: %w", snapshotID, pv.Name, err)
+	}
+
+	klog.V(2).InfoS("Took pre-modification snapshot", "pv", pv.Name, "snapshotID", snapshotID)
+	return updated, nil
+}
+
+// maybeGCSnapshot deletes pv's recorded pre-modification snapshot once
+// snapshotRetention has elapsed since it was taken. It is only invoked once a
+// modification has already succeeded (the desired parameters are already
+// applied), so the snapshot is no longer needed as a rollback point.
+func (ctrl *ModifyController) maybeGCSnapshot(ctx context.Context, pv *v1.PersistentVolume) {
+	maybeGCSnapshot(ctx, ctrl.snapshotDeps(), pv)
+}
+
+// maybeGCSnapshot behaves the same as ModifyController's method of the same
+// name, against the CRD reconcile path's own kubeClient. It is only invoked
+// once a VolumeModification has already reached Succeeded.
+func (ctrl *CRDModifyController) maybeGCSnapshot(ctx context.Context, pv *v1.PersistentVolume) {
+	maybeGCSnapshot(ctx, ctrl.snapshotDeps(), pv)
+}
+
+func maybeGCSnapshot(ctx context.Context, deps snapshotDeps, pv *v1.PersistentVolume) {
+	if deps.snapshotRetention <= 0 {
+		return
+	}
+
+	snapshotID := pv.Annotations[snapshotAnnotationKey(deps.name)]
+	if snapshotID == "" {
+		return
+	}
+
+	takenAtRaw := pv.Annotations[snapshotTimeAnnotationKey(deps.name)]
+	takenAtUnix, err := strconv.ParseInt(takenAtRaw, 10, 64)
+	if err != nil {
+		return
+	}
+
+	if time.Since(time.Unix(takenAtUnix, 0)) < deps.snapshotRetention {
+		return
+	}
+
+	if err := deps.modifier.DeleteSnapshot(ctx, snapshotID); err != nil {
+		klog.Errorf("Failed to garbage collect pre-modification snapshot %q for PV %q: %v", snapshotID, pv.Name, err)
+		return
+	}
+
+	pvClone := pv.DeepCopy()
+	delete(pvClone.Annotations, snapshotAnnotationKey(deps.name))
+	delete(pvClone.Annotations, snapshotTimeAnnotationKey(deps.name))
+	if _, err := deps.kubeClient.CoreV1().PersistentVolumes().Update(ctx, pvClone, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Failed to clear garbage collected snapshot annotations on PV %q: %v", pv.Name, err)
+	}
+}
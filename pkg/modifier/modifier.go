@@ -0,0 +1,87 @@
+// Package modifier bridges the Kubernetes-facing controller with the CSI
+// client, translating PersistentVolume modification requests into
+// ModifyVolumeProperties RPCs.
+package modifier
+
+import (
+	"context"
+	"time"
+
+	csi "github.com/awslabs/volume-modifier-for-k8s/pkg/client"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Modifier applies a set of parameters to an existing volume via the CSI
+// driver associated with a PersistentVolume.
+type Modifier interface {
+	// Name returns the CSI driver name this Modifier talks to.
+	Name() string
+
+	// Modify issues a ModifyVolumeProperties call for the volume backing pv,
+	// passing params as the requested modification parameters.
+	Modify(ctx context.Context, pv *v1.PersistentVolume, params map[string]string) error
+
+	// Snapshot takes a CreateSnapshot of the volume backing pv, to be used as
+	// a rollback point before a modification is attempted. snapshotName is
+	// passed to the driver as the CreateSnapshot name; the controllers derive
+	// it from the PV name and PVC resourceVersion, so a retry of the same
+	// request reuses the same name.
+	Snapshot(ctx context.Context, pv *v1.PersistentVolume, snapshotName string) (snapshotID string, err error)
+
+	// DeleteSnapshot removes a snapshot previously returned by Snapshot, once
+	// it is no longer needed as a rollback point.
+	DeleteSnapshot(ctx context.Context, snapshotID string) error
+}
+
+type modifier struct {
+	name       string
+	csiClient  csi.Client
+	kubeClient kubernetes.Interface
+	timeout    time.Duration
+}
+
+// NewFromClient builds a Modifier that talks to the CSI driver identified by
+// driverName through csiClient.
+func NewFromClient(driverName string, csiClient csi.Client, kubeClient kubernetes.Interface, timeout time.Duration) (Modifier, error) {
+	return &modifier{
+		name:       driverName,
+		csiClient:  csiClient,
+		kubeClient: kubeClient,
+		timeout:    timeout,
+	}, nil
+}
+
+func (m *modifier) Name() string {
+	return m.name
+}
+
+func (m *modifier) Modify(ctx context.Context, pv *v1.PersistentVolume, params map[string]string) error {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	volumeID := pv.Spec.CSI.VolumeHandle
+	reqContext := map[string]string{}
+	return m.csiClient.Modify(ctx, volumeID, params, reqContext)
+}
+
+func (m *modifier) Snapshot(ctx context.Context, pv *v1.PersistentVolume, snapshotName string) (string, error) {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	return m.csiClient.CreateSnapshot(ctx, pv.Spec.CSI.VolumeHandle, snapshotName, nil)
+}
+
+func (m *modifier) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	return m.csiClient.DeleteSnapshot(ctx, snapshotID)
+}
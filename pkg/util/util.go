@@ -0,0 +1,11 @@
+// Package util contains small helpers shared across the modifier packages.
+package util
+
+import "strings"
+
+// SanitizeName replaces characters that are not allowed in Kubernetes object
+// names (e.g. the "/" and "." found in CSI driver names) with "-" so the
+// result can safely be used as part of a lease or object name.
+func SanitizeName(name string) string {
+	return strings.NewReplacer("/", "-", ".", "-").Replace(name)
+}
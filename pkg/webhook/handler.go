@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	csi "github.com/awslabs/volume-modifier-for-k8s/pkg/client"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+var (
+	scheme = runtime.NewScheme()
+	codecs = serializer.NewCodecFactory(scheme)
+)
+
+func init() {
+	utilruntimeMust(admissionv1.AddToScheme(scheme))
+	utilruntimeMust(v1.AddToScheme(scheme))
+}
+
+func utilruntimeMust(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handler serves a ValidatingAdmissionWebhook for PersistentVolumeClaim
+// CREATE and UPDATE.
+type Handler struct {
+	KubeClient                   kubernetes.Interface
+	CSIClient                    csi.Client
+	DriverName                   string
+	EnableVolumeTypeModification bool
+
+	capability atomic.Value // *csi.ModificationCapability
+}
+
+// StartCapabilityRefresh fetches the driver's modification parameter schema
+// once synchronously, so the webhook never serves before it has one, and
+// again in the background every interval, so a driver-side schema change
+// doesn't require restarting the webhook.
+func (h *Handler) StartCapabilityRefresh(ctx context.Context, interval time.Duration) error {
+	if err := h.refreshCapability(ctx); err != nil {
+		return fmt.Errorf("failed to fetch initial modification capability: %w", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := h.refreshCapability(ctx); err != nil {
+					klog.Errorf("Failed to refresh modification capability, keeping previous schema: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (h *Handler) refreshCapability(ctx context.Context) error {
+	capability, err := h.CSIClient.GetModificationCapability(ctx)
+	if err != nil {
+		return err
+	}
+	h.capability.Store(capability)
+	return nil
+}
+
+// ServeHTTP decodes an AdmissionReview, validates the PVC it carries, and
+// writes back the allow/deny decision.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review, err := decodeReview(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeReview(w, review, h.review(r.Context(), review))
+}
+
+func (h *Handler) review(ctx context.Context, review *admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
+	req := review.Request
+
+	var newPVC v1.PersistentVolumeClaim
+	if err := json.Unmarshal(req.Object.Raw, &newPVC); err != nil {
+		return deny(req.UID, fmt.Errorf("failed to decode PVC: %w", err))
+	}
+
+	var oldPVC *v1.PersistentVolumeClaim
+	if len(req.OldObject.Raw) > 0 {
+		oldPVC = &v1.PersistentVolumeClaim{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldPVC); err != nil {
+			return deny(req.UID, fmt.Errorf("failed to decode old PVC: %w", err))
+		}
+	}
+
+	capability, _ := h.capability.Load().(*csi.ModificationCapability)
+	if capability == nil {
+		return deny(req.UID, fmt.Errorf("modification capability for driver %q is not yet known", h.DriverName))
+	}
+
+	var pv *v1.PersistentVolume
+	if newPVC.Spec.VolumeName != "" {
+		fetched, err := h.KubeClient.CoreV1().PersistentVolumes().Get(ctx, newPVC.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return deny(req.UID, fmt.Errorf("failed to get PersistentVolume %q: %w", newPVC.Spec.VolumeName, err))
+		}
+		pv = fetched
+	}
+
+	if err := ValidatePVCUpdate(oldPVC, &newPVC, pv, h.DriverName, capability, h.EnableVolumeTypeModification); err != nil {
+		return deny(req.UID, err)
+	}
+	return allow(req.UID)
+}
+
+func decodeReview(r *http.Request) (*admissionv1.AdmissionReview, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	review := &admissionv1.AdmissionReview{}
+	if _, _, err := codecs.UniversalDeserializer().Decode(body, nil, review); err != nil {
+		return nil, fmt.Errorf("failed to decode AdmissionReview: %w", err)
+	}
+	if review.Request == nil {
+		return nil, fmt.Errorf("AdmissionReview carried no request")
+	}
+	return review, nil
+}
+
+func writeReview(w http.ResponseWriter, review *admissionv1.AdmissionReview, response *admissionv1.AdmissionResponse) {
+	out := &admissionv1.AdmissionReview{
+		TypeMeta: review.TypeMeta,
+		Response: response,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		klog.Errorf("Failed to write AdmissionReview response: %v", err)
+	}
+}
+
+func allow(uid types.UID) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{UID: uid, Allowed: true}
+}
+
+func deny(uid types.UID, err error) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		UID:     uid,
+		Allowed: false,
+		Result:  &metav1.Status{Message: err.Error()},
+	}
+}
@@ -0,0 +1,117 @@
+// Package webhook implements a ValidatingAdmissionWebhook for
+// PersistentVolumeClaim CREATE and UPDATE, rejecting
+// "<driverName>/<parameter>" annotation changes the CSI driver doesn't
+// support, or that fall outside the bounds it advertises, before they ever
+// reach ModifyController.
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	csi "github.com/awslabs/volume-modifier-for-k8s/pkg/client"
+	v1 "k8s.io/api/core/v1"
+)
+
+// volumeTypeParameterSuffix mirrors the constant ModifyController uses to
+// gate volume type changes behind --enable-volume-type-modification.
+const volumeTypeParameterSuffix = "volumeType"
+
+// disablePreModifySnapshotAnnotationSuffix mirrors the constant
+// ModifyController uses for the per-PVC pre-modify-snapshot opt-out. It
+// isn't a CSI modification parameter, so it must never be treated as one
+// here, the same as in ModifyController.modifiableParameters.
+const disablePreModifySnapshotAnnotationSuffix = "disable-pre-modify-snapshot"
+
+// nonParameterAnnotationSuffixes mirrors ModifyController's set of the same
+// name: driver-prefixed PVC annotation suffixes that control the modifier's
+// own behavior rather than naming a CSI ModifyVolume parameter, and so must
+// be excluded from modifiableParameters here just as they are there.
+var nonParameterAnnotationSuffixes = map[string]bool{
+	disablePreModifySnapshotAnnotationSuffix: true,
+}
+
+// ValidatePVCUpdate rejects a PVC create or update if its
+// "<driverName>/<parameter>" annotations request a modification the driver
+// doesn't support, fall outside capability's advertised bounds, or change
+// only the volume type while that's disabled. oldPVC is nil for a CREATE.
+// pv is nil if the PVC isn't bound yet, in which case the driver match check
+// is skipped but the parameter checks still apply, since the annotations
+// will be reconciled once it binds.
+func ValidatePVCUpdate(oldPVC, newPVC *v1.PersistentVolumeClaim, pv *v1.PersistentVolume, driverName string, capability *csi.ModificationCapability, enableVolumeTypeModification bool) error {
+	changed := changedParameters(modifiableParameters(oldPVC, driverName), modifiableParameters(newPVC, driverName))
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if pv != nil && pv.Spec.CSI != nil && pv.Spec.CSI.Driver != driverName {
+		return fmt.Errorf("PVC %s/%s is bound to a PersistentVolume owned by driver %q, not %q", newPVC.Namespace, newPVC.Name, pv.Spec.CSI.Driver, driverName)
+	}
+
+	if !enableVolumeTypeModification && onlyVolumeTypeChanged(changed, driverName) {
+		return fmt.Errorf("modifying %q alone is disabled; set --enable-volume-type-modification to allow it", driverName+"/"+volumeTypeParameterSuffix)
+	}
+
+	for key, value := range changed {
+		paramName := strings.TrimPrefix(key, driverName+"/")
+		constraint, ok := capability.Parameters[paramName]
+		if !ok {
+			return fmt.Errorf("driver %q does not support modifying %q", driverName, paramName)
+		}
+
+		if constraint == (csi.ParameterConstraint{}) {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q must be an integer, got %q", key, value)
+		}
+		if n < constraint.Min || n > constraint.Max {
+			return fmt.Errorf("%q must be between %d and %d, got %d", key, constraint.Min, constraint.Max, n)
+		}
+	}
+
+	return nil
+}
+
+// modifiableParameters returns pvc's annotations prefixed with
+// "<driverName>/". pvc may be nil, in which case it returns an empty map,
+// so callers can pass a CREATE's nil oldPVC uniformly.
+func modifiableParameters(pvc *v1.PersistentVolumeClaim, driverName string) map[string]string {
+	if pvc == nil {
+		return nil
+	}
+	prefix := driverName + "/"
+	params := map[string]string{}
+	for k, v := range pvc.Annotations {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if nonParameterAnnotationSuffixes[strings.TrimPrefix(k, prefix)] {
+			continue
+		}
+		params[k] = v
+	}
+	return params
+}
+
+// changedParameters returns the entries of newParams that are absent from,
+// or have a different value in, oldParams.
+func changedParameters(oldParams, newParams map[string]string) map[string]string {
+	changed := map[string]string{}
+	for k, v := range newParams {
+		if oldParams[k] != v {
+			changed[k] = v
+		}
+	}
+	return changed
+}
+
+func onlyVolumeTypeChanged(changed map[string]string, driverName string) bool {
+	if len(changed) != 1 {
+		return false
+	}
+	_, ok := changed[driverName+"/"+volumeTypeParameterSuffix]
+	return ok
+}
@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"testing"
+
+	csi "github.com/awslabs/volume-modifier-for-k8s/pkg/client"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const driverName = "ebs.csi.aws.com"
+
+func TestValidatePVCUpdate(t *testing.T) {
+	capability := &csi.ModificationCapability{
+		Parameters: map[string]csi.ParameterConstraint{
+			"iops":       {Min: 100, Max: 10000},
+			"volumeType": {},
+		},
+	}
+
+	testCases := []struct {
+		name                         string
+		oldPVC                       *v1.PersistentVolumeClaim
+		newPVC                       *v1.PersistentVolumeClaim
+		pv                           *v1.PersistentVolume
+		enableVolumeTypeModification bool
+		wantErr                      bool
+	}{
+		{
+			name:   "no modifiable annotations changed",
+			oldPVC: newPVC(map[string]string{"foo": "bar"}),
+			newPVC: newPVC(map[string]string{"foo": "baz"}),
+		},
+		{
+			name:   "in-bounds parameter change is allowed",
+			oldPVC: newPVC(nil),
+			newPVC: newPVC(map[string]string{driverName + "/iops": "5000"}),
+		},
+		{
+			name:    "parameter below the advertised minimum is rejected",
+			oldPVC:  newPVC(nil),
+			newPVC:  newPVC(map[string]string{driverName + "/iops": "50"}),
+			wantErr: true,
+		},
+		{
+			name:    "parameter above the advertised maximum is rejected",
+			oldPVC:  newPVC(nil),
+			newPVC:  newPVC(map[string]string{driverName + "/iops": "20000"}),
+			wantErr: true,
+		},
+		{
+			name:    "non-integer value for a bounded parameter is rejected",
+			oldPVC:  newPVC(nil),
+			newPVC:  newPVC(map[string]string{driverName + "/iops": "fast"}),
+			wantErr: true,
+		},
+		{
+			name:    "parameter the driver doesn't advertise is rejected",
+			oldPVC:  newPVC(nil),
+			newPVC:  newPVC(map[string]string{driverName + "/throughput": "500"}),
+			wantErr: true,
+		},
+		{
+			name:                         "volume type alone is allowed when enabled",
+			oldPVC:                       newPVC(nil),
+			newPVC:                       newPVC(map[string]string{driverName + "/volumeType": "io2"}),
+			enableVolumeTypeModification: true,
+		},
+		{
+			name:    "volume type alone is rejected when disabled",
+			oldPVC:  newPVC(nil),
+			newPVC:  newPVC(map[string]string{driverName + "/volumeType": "io2"}),
+			wantErr: true,
+		},
+		{
+			name:   "volume type alongside another parameter is allowed even when disabled",
+			oldPVC: newPVC(nil),
+			newPVC: newPVC(map[string]string{
+				driverName + "/volumeType": "io2",
+				driverName + "/iops":       "5000",
+			}),
+		},
+		{
+			name:    "PV bound to a different driver is rejected",
+			oldPVC:  newPVC(nil),
+			newPVC:  newPVC(map[string]string{driverName + "/iops": "5000"}),
+			pv:      newPV("other.csi.example.com"),
+			wantErr: true,
+		},
+		{
+			name:    "unbound PVC still validates parameter bounds",
+			oldPVC:  newPVC(nil),
+			newPVC:  newPVC(map[string]string{driverName + "/iops": "50"}),
+			pv:      nil,
+			wantErr: true,
+		},
+		{
+			name:   "pre-modify-snapshot opt-out annotation is allowed untouched",
+			oldPVC: newPVC(nil),
+			newPVC: newPVC(map[string]string{driverName + "/disable-pre-modify-snapshot": "true"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePVCUpdate(tc.oldPVC, tc.newPVC, tc.pv, driverName, capability, tc.enableVolumeTypeModification)
+			if tc.wantErr && err == nil {
+				t.Errorf("expected an error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func newPVC(annotations map[string]string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "testPVC",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+}
+
+func newPV(driver string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "testPV"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: driver},
+			},
+		},
+	}
+}